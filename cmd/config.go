@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/dsrosen6/hyprdocked/internal/app"
 	"github.com/spf13/cobra"
@@ -19,6 +22,7 @@ var checkCfgCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		var cfg app.Config
 		cobra.CheckErr(viper.Unmarshal(&cfg))
+		cobra.CheckErr(cfg.Validate())
 
 		sw := cfg.SettleWindow
 		if sw <= 0 {
@@ -26,11 +30,176 @@ var checkCfgCmd = &cobra.Command{
 		}
 
 		fmt.Printf("%-25s %v\n", "Debug:", cfg.Debug)
-		fmt.Printf("%-25s %s\n", "Laptop:", cfg.Laptop)
+		laptop := cfg.Laptop
+		if laptop == "" {
+			laptop = "auto-detect"
+		}
+		fmt.Printf("%-25s %s\n", "Laptop:", laptop)
+		if cfg.LaptopMatch != "" {
+			fmt.Printf("%-25s %s\n", "Laptop Match:", cfg.LaptopMatch)
+		}
+		for _, p := range cfg.Panels {
+			docked := p.Docked
+			if docked == "" {
+				docked = "follow"
+			}
+			fmt.Printf("%-25s %s (docked: %s)\n", "Internal Panel:", p.Name, docked)
+		}
 		fmt.Printf("%-25s %v\n", "Suspend On Idle:", cfg.SuspendIdle)
 		fmt.Printf("%-25s %v\n", "Suspend On Closed:", cfg.SuspendClosed)
+		fmt.Printf("%-25s %s\n", "Suspend Idle Action:", cfg.SuspendIdleAction)
+		fmt.Printf("%-25s %s\n", "Suspend Closed Action:", cfg.SuspendClosedAction)
+		fmt.Printf("%-25s %v\n", "Suspend Only On Battery:", cfg.SuspendClosedBatteryOnly)
+		fmt.Printf("%-25s %v\n", "Logind Sleep:", cfg.LogindSleep)
+		fmt.Printf("%-25s %v\n", "Watch Connectors:", cfg.WatchConnectors)
+		fmt.Printf("%-25s %s\n", "Lid Backend:", cfg.LidBackend)
+		if cfg.LidDevice != "" {
+			fmt.Printf("%-25s %s\n", "Lid Device:", cfg.LidDevice)
+		}
 		fmt.Printf("%-25s %v\n", "Sequential Hooks:", cfg.SequentialHooks)
 		fmt.Printf("%-25s %ds\n", "Settle Window:", sw)
+		fmt.Printf("%-25s %s\n", "Closed Strategy:", cfg.ClosedStrategy)
+		fmt.Printf("%-25s %v\n", "Migrate Workspaces:", cfg.MigrateWorkspaces)
+		if cfg.MigrateWorkspaces {
+			wt := cfg.WorkspaceTarget
+			if wt == "" {
+				wt = "focused external"
+			}
+			fmt.Printf("%-25s %s\n", "Workspace Target:", wt)
+		}
+
+		fmt.Printf("%-25s", "Docks:")
+		if len(cfg.Docks) == 0 {
+			fmt.Println(" None")
+		} else {
+			fmt.Println()
+			for _, d := range cfg.Docks {
+				fmt.Printf("  %-23s %s\n", "Name:", d.Name)
+				if d.USB != "" {
+					fmt.Printf("  %-23s %s\n", "USB:", d.USB)
+				}
+				if d.Thunderbolt != "" {
+					fmt.Printf("  %-23s %s\n", "Thunderbolt:", d.Thunderbolt)
+				}
+				for k, v := range d.Attrs {
+					fmt.Printf("  %-23s %s=%s\n", "Attribute:", k, v)
+				}
+			}
+		}
+
+		fmt.Printf("%-25s", "Low Battery:")
+		if bp := cfg.Battery; bp.LowPercent <= 0 && bp.LowMinutes <= 0 {
+			fmt.Println(" Disabled")
+		} else {
+			fmt.Println()
+			fmt.Printf("  %-23s %v%%\n", "Percent:", bp.LowPercent)
+			fmt.Printf("  %-23s %dm\n", "Minutes Remaining:", bp.LowMinutes)
+			fmt.Printf("  %-23s %s\n", "Closed Action:", bp.ClosedAction)
+			fmt.Printf("  %-23s %v\n", "Refresh Rate:", bp.LowRefreshRate)
+		}
+
+		fmt.Printf("%-25s", "Laptop Overrides:")
+		if len(cfg.LaptopOverrides) == 0 {
+			fmt.Println(" None")
+		} else {
+			fmt.Println()
+			for _, st := range slices.Sorted(maps.Keys(cfg.LaptopOverrides)) {
+				o := cfg.LaptopOverrides[st]
+				fmt.Printf("  %-23s %s\n", "Status:", st)
+				if o.Resolution != "" {
+					fmt.Printf("    %-21s %s\n", "Resolution:", o.Resolution)
+				}
+				if o.RefreshRate > 0 {
+					fmt.Printf("    %-21s %v\n", "Refresh Rate:", o.RefreshRate)
+				}
+				if o.Scale > 0 {
+					fmt.Printf("    %-21s %v\n", "Scale:", o.Scale)
+				}
+				if o.Transform != nil {
+					fmt.Printf("    %-21s %d\n", "Transform:", *o.Transform)
+				}
+			}
+		}
+
+		fmt.Printf("%-25s", "Laptop Placement:")
+		if lp := cfg.LaptopPlacement; lp.Side == "" {
+			fmt.Println(" Disabled")
+		} else {
+			fmt.Println()
+			align, primary := lp.Align, lp.Primary
+			if align == "" {
+				align = "start"
+			}
+			if primary == "" {
+				primary = "top-left external"
+			}
+			fmt.Printf("  %-23s %s\n", "Side:", lp.Side)
+			fmt.Printf("  %-23s %s\n", "Align:", align)
+			fmt.Printf("  %-23s %s\n", "Primary:", primary)
+		}
+
+		fmt.Printf("%-25s", "Mirror:")
+		if mp := cfg.Mirror; len(mp.Match) == 0 && !mp.Unprofiled {
+			fmt.Println(" Disabled")
+		} else {
+			fmt.Println()
+			for _, m := range mp.Match {
+				fmt.Printf("  %-23s %s\n", "Match:", m)
+			}
+			fmt.Printf("  %-23s %v\n", "Unprofiled:", mp.Unprofiled)
+		}
+
+		fmt.Printf("%-25s", "Display Profiles:")
+		if len(cfg.DisplayProfiles) == 0 {
+			fmt.Println(" None")
+		} else {
+			fmt.Println()
+			for _, p := range cfg.DisplayProfiles {
+				fmt.Printf("  %-23s %s\n", "Name:", p.Name)
+				for _, m := range p.Monitors {
+					fmt.Printf("  %-23s %s\n", "Monitor:", m.Match)
+				}
+				if p.Laptop != nil {
+					laptop := "settings"
+					if p.Laptop.Disabled {
+						laptop = "disabled"
+					}
+					fmt.Printf("  %-23s %s\n", "Laptop:", laptop)
+				}
+			}
+		}
+
+		fmt.Printf("%-25s", "Power Profiles:")
+		if len(cfg.PowerProfiles) == 0 {
+			fmt.Println(" None")
+		} else {
+			fmt.Println()
+			for _, p := range cfg.PowerProfiles {
+				st, pw := p.Status, p.Power
+				if st == "" {
+					st = "any"
+				}
+				if pw == "" {
+					pw = "any"
+				}
+				fmt.Printf("  %-23s %s (status: %s, power: %s)\n", "Profile:", p.Profile, st, pw)
+			}
+		}
+
+		fmt.Printf("%-25s", "Rules:")
+		if len(cfg.Rules) == 0 {
+			fmt.Println(" Defaults")
+		} else {
+			fmt.Println()
+			for _, r := range cfg.Rules {
+				actions := make([]string, 0, len(r.Actions))
+				for _, a := range r.Actions {
+					actions = append(actions, a.Action)
+				}
+				fmt.Printf("  %-23s %s\n", "Name:", r.Name)
+				fmt.Printf("  %-23s %s\n", "Actions:", strings.Join(actions, ", "))
+			}
+		}
 
 		fmt.Printf("%-25s", "Post Hooks:")
 		if len(cfg.PostUpdateHooks) == 0 {
@@ -40,6 +209,9 @@ var checkCfgCmd = &cobra.Command{
 			for _, h := range cfg.PostUpdateHooks {
 				fmt.Printf("  %-23s %s\n", "Command:", h.Command)
 				fmt.Printf("  %-23s %v\n", "On Status Change:", h.OnStatusChange)
+				if h.Power != "" {
+					fmt.Printf("  %-23s %s\n", "Power:", h.Power)
+				}
 			}
 		}
 	},
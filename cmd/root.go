@@ -81,18 +81,40 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "enable debug logging")
-	rootCmd.PersistentFlags().StringP("laptop", "l", "eDP-1", "laptop monitor name")
+	rootCmd.PersistentFlags().StringP("laptop", "l", "", "laptop monitor name (default detected from its DRM connector type)")
+	rootCmd.PersistentFlags().String("laptop-match", "", "regex matching the names or descriptions of internal panels that can't be detected")
 	rootCmd.PersistentFlags().Bool("suspend-idle", false, "suspend device when idle command is sent")
 	rootCmd.PersistentFlags().Bool("suspend-closed", false, "suspend device on lid closed if only laptop")
+	rootCmd.PersistentFlags().String("suspend-idle-action", "suspend", "sleep action for suspend on idle: suspend, hibernate, hybrid-sleep or suspend-then-hibernate")
+	rootCmd.PersistentFlags().String("suspend-closed-action", "suspend", "sleep action for suspend on lid closed: suspend, hibernate, hybrid-sleep or suspend-then-hibernate")
+	rootCmd.PersistentFlags().Bool("suspend-closed-battery-only", false, "only suspend on lid closed when running on battery")
+	rootCmd.PersistentFlags().Bool("logind-sleep", true, "enter idle mode automatically when logind prepares for sleep, and resume on wake")
+	rootCmd.PersistentFlags().String("lid-backend", "auto", "where to read the lid state from: auto, upower, logind, acpi or evdev")
+	rootCmd.PersistentFlags().String("lid-device", "", "evdev device for the evdev lid backend (default detected from sysfs)")
+	rootCmd.PersistentFlags().Bool("watch-connectors", true, "watch DRM connector hotplug events alongside hyprland's monitor events")
 	rootCmd.PersistentFlags().Bool("sequential-hooks", false, "run post-hooks sequentially instead of concurrently")
 	rootCmd.PersistentFlags().Int("settle-window", 3, "seconds to wait after an event before processing (default 3)")
+	rootCmd.PersistentFlags().Bool("migrate-workspaces", false, "move laptop workspaces to an external display when disabling the laptop display, and back when re-enabling it")
+	rootCmd.PersistentFlags().String("closed-strategy", "disable", "how to turn off the laptop display when docked with lid closed: disable, dpms or offset")
+	rootCmd.PersistentFlags().String("workspace-target", "", "name or description of the external display to move laptop workspaces to (default focused external)")
 
 	_ = viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
 	_ = viper.BindPFlag("laptop", rootCmd.PersistentFlags().Lookup("laptop"))
+	_ = viper.BindPFlag("laptop-match", rootCmd.PersistentFlags().Lookup("laptop-match"))
 	_ = viper.BindPFlag("suspend-idle", rootCmd.PersistentFlags().Lookup("suspend-idle"))
 	_ = viper.BindPFlag("suspend-closed", rootCmd.PersistentFlags().Lookup("suspend-closed"))
+	_ = viper.BindPFlag("suspend-idle-action", rootCmd.PersistentFlags().Lookup("suspend-idle-action"))
+	_ = viper.BindPFlag("suspend-closed-action", rootCmd.PersistentFlags().Lookup("suspend-closed-action"))
+	_ = viper.BindPFlag("suspend-closed-battery-only", rootCmd.PersistentFlags().Lookup("suspend-closed-battery-only"))
+	_ = viper.BindPFlag("logind-sleep", rootCmd.PersistentFlags().Lookup("logind-sleep"))
+	_ = viper.BindPFlag("lid-backend", rootCmd.PersistentFlags().Lookup("lid-backend"))
+	_ = viper.BindPFlag("lid-device", rootCmd.PersistentFlags().Lookup("lid-device"))
+	_ = viper.BindPFlag("watch-connectors", rootCmd.PersistentFlags().Lookup("watch-connectors"))
 	_ = viper.BindPFlag("sequential-hooks", rootCmd.PersistentFlags().Lookup("sequential-hooks"))
 	_ = viper.BindPFlag("settle-window", rootCmd.PersistentFlags().Lookup("settle-window"))
+	_ = viper.BindPFlag("migrate-workspaces", rootCmd.PersistentFlags().Lookup("migrate-workspaces"))
+	_ = viper.BindPFlag("closed-strategy", rootCmd.PersistentFlags().Lookup("closed-strategy"))
+	_ = viper.BindPFlag("workspace-target", rootCmd.PersistentFlags().Lookup("workspace-target"))
 
 	idleCmd.Flags().String("source", "", "source of the idle command (logged by listener)")
 	resumeCmd.Flags().String("source", "", "source of the resume command (logged by listener)")
@@ -2,21 +2,25 @@ package app
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
 	"time"
 
 	"github.com/dsrosen6/hyprdocked/internal/hypr"
 	"github.com/dsrosen6/hyprdocked/internal/power"
+	"github.com/dsrosen6/hyprdocked/internal/udev"
 	"github.com/godbus/dbus/v5"
 	"github.com/spf13/viper"
 )
 
 type App struct {
 	Config            Config
-	hctl              *hypr.Client
+	hctl              hypr.Controller
 	listener          *listener
+	sleeper           power.SessionController
+	profiles          power.ProfileController
+	powerProfile      profileTracker
 	updating          bool
 	configReloadTimer *time.Timer
 	*state
@@ -28,7 +32,7 @@ type RunParams struct {
 	SuspendOnClosed   bool
 }
 
-func newApp(cfg Config, hc *hypr.Client, l *listener, s *state) *App {
+func newApp(cfg Config, hc hypr.Controller, l *listener, s *state) *App {
 	return &App{
 		Config:   cfg,
 		hctl:     hc,
@@ -38,22 +42,23 @@ func newApp(cfg Config, hc *hypr.Client, l *listener, s *state) *App {
 }
 
 func RunListener(c Config) error {
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
 	hypr.WaitForEnvs()
-	if c.Laptop == "" {
-		return errors.New("laptop monitor name cannot be empty")
+	var laptopMatch *regexp.Regexp
+	if c.LaptopMatch != "" {
+		re, err := regexp.Compile(c.LaptopMatch)
+		if err != nil {
+			return fmt.Errorf("invalid laptop-match pattern: %w", err)
+		}
+		laptopMatch = re
 	}
 
 	hyprClient, err := hypr.NewClient()
 	if err != nil {
-		return fmt.Errorf("creating hyprctl client: %w", err)
-	}
-
-	// Run an initial reload in case laptop display is already disabled. Assuming the laptop
-	// display is correctly set to initially enable in the hyprland config, this will re-enable
-	// it so hyprdocked can properly identify it.
-	slog.Info("running hyprctl reload")
-	if err := hyprClient.Reload(); err != nil {
-		return fmt.Errorf("running hyprctl reload: %w", err)
+		return fmt.Errorf("creating hyprland client: %w", err)
 	}
 
 	var (
@@ -84,11 +89,54 @@ func RunListener(c Config) error {
 		return fmt.Errorf("creating dbus connection: %w", err)
 	}
 
-	lh := power.NewLidHandler(dbusConn)
+	lidSrc, lb, err := power.NewLidSource(context.Background(), dbusConn, power.LidBackend(c.LidBackend), c.LidDevice)
+	if err != nil {
+		return fmt.Errorf("creating lid source: %w", err)
+	}
+	slog.Info("using lid backend", "backend", lb)
+
+	ph := power.NewHandler(dbusConn)
+	bh := power.NewBatteryHandler(dbusConn)
+
+	// left as nil interfaces, not typed nils, so the listener can tell they're disabled
+	var (
+		sh  power.SleepSource
+		inh power.Inhibitor
+	)
+	if c.LogindSleep {
+		sh = power.NewSleepHandler(dbusConn)
+		inh = power.NewSleepInhibitor(dbusConn)
+	}
+	sysRoot := udev.DefaultSysRoot
+	var um *udev.Monitor
+	if len(c.Docks) > 0 || c.WatchConnectors {
+		um, err = udev.NewMonitor()
+		switch {
+		case err == nil:
+			defer func() {
+				_ = um.Close()
+			}()
+		case len(c.Docks) > 0:
+			return fmt.Errorf("creating udev monitor: %w", err)
+		default:
+			// connectors only cross-check Hyprland, so carry on without them
+			slog.Warn("creating udev monitor; not watching connectors", "error", err)
+			um = nil
+		}
+	}
+
 	lp := listenerParams{
-		hyprSockConn: hyprSock,
-		lidHandler:   lh,
-		dbusConn:     dbusConn,
+		hyprSockConn:    hyprSock,
+		lidSource:       lidSrc,
+		powerSource:     ph,
+		batterySource:   bh,
+		sleepSource:     sh,
+		sleepInhibitor:  inh,
+		udevMonitor:     um,
+		docks:           c.Docks,
+		watchConnectors: c.WatchConnectors,
+		sysRoot:         sysRoot,
+		dbusConn:        dbusConn,
 	}
 
 	l, err := newListener(lp)
@@ -98,8 +146,15 @@ func RunListener(c Config) error {
 
 	sp := initialStateParams{
 		laptopMonitorName: c.Laptop,
+		laptopMatch:       laptopMatch,
 		hyprClient:        hyprClient,
-		lidHandler:        lh,
+		lidSource:         lidSrc,
+		powerSource:       ph,
+		batterySource:     bh,
+		docks:             c.Docks,
+		watchConnectors:   c.WatchConnectors,
+		panels:            c.Panels,
+		sysRoot:           sysRoot,
 	}
 
 	s, err := getInitialState(context.Background(), sp)
@@ -108,14 +163,25 @@ func RunListener(c Config) error {
 	}
 
 	a := newApp(c, hyprClient, l, s)
+	a.sleeper = power.NewSleeper(dbusConn)
+	a.profiles = power.NewProfileManager(dbusConn)
 	slog.Info("app initialized",
 		"laptop_monitor_name", a.laptopDisplay.Name,
+		"panels", len(a.panels),
 		"status", a.statusString(),
+		"power", a.powerState,
+		"battery_percentage", a.battery.Percentage,
+		"docks", a.docks,
 		"suspend_idle", a.Config.SuspendIdle,
 		"suspend_closed", a.Config.SuspendClosed,
+		"logind_sleep", a.Config.LogindSleep,
 	)
 
-	// initial updater run before starting listener
+	// Hold a delay lock so the laptop display can be re-enabled before the system sleeps.
+	a.acquireSleepInhibitor(context.Background())
+
+	// initial updater run before starting listener, applying a display profile if one matches
+	a.checkMonitorSet()
 	changed, _ := a.runUpdater()
 	a.runPostHooks(changed)
 
@@ -0,0 +1,551 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/hypr/hyprtest"
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+// fakeSource is an in-memory lid, power or battery source. set changes the state and signals a
+// change, as the D-Bus handlers do on a PropertiesChanged signal.
+type fakeSource[T any] struct {
+	mu      sync.Mutex
+	val     T
+	changes chan struct{}
+}
+
+func newFakeSource[T any](v T) *fakeSource[T] {
+	return &fakeSource[T]{val: v, changes: make(chan struct{}, 10)}
+}
+
+func (f *fakeSource[T]) GetCurrentState(context.Context) (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.val, nil
+}
+
+func (f *fakeSource[T]) ListenForChanges(ctx context.Context) error {
+	<-ctx.Done()
+	close(f.changes)
+	return ctx.Err()
+}
+
+func (f *fakeSource[T]) Changes() <-chan struct{} {
+	return f.changes
+}
+
+func (f *fakeSource[T]) set(v T) {
+	f.mu.Lock()
+	f.val = v
+	f.mu.Unlock()
+	f.changes <- struct{}{}
+}
+
+type fakeSleepSource struct {
+	changes chan bool
+}
+
+func (f *fakeSleepSource) ListenForChanges(ctx context.Context) error {
+	<-ctx.Done()
+	close(f.changes)
+	return ctx.Err()
+}
+
+func (f *fakeSleepSource) Changes() <-chan bool {
+	return f.changes
+}
+
+type fakeInhibitor struct {
+	mu       sync.Mutex
+	held     bool
+	released int
+}
+
+func (f *fakeInhibitor) Acquire(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.held = true
+	return nil
+}
+
+func (f *fakeInhibitor) Release() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.held = false
+	f.released++
+	return nil
+}
+
+func (f *fakeInhibitor) Held() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.held
+}
+
+func (f *fakeInhibitor) releases() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.released
+}
+
+// fakeSession records sleep and lock requests instead of making them.
+type fakeSession struct {
+	mu     sync.Mutex
+	sleeps []power.SleepAction
+	locks  int
+}
+
+func (f *fakeSession) Sleep(_ context.Context, action power.SleepAction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sleeps = append(f.sleeps, action)
+	return nil
+}
+
+func (f *fakeSession) Lock(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.locks++
+	return nil
+}
+
+var (
+	testLaptop   = hypr.Monitor{Name: "eDP-1", Description: "BOE 0x0BCA", Width: 1920, Height: 1200, RefreshRate: 60, Scale: 1}
+	testExternal = hypr.Monitor{Name: "DP-1", Description: "Dell Inc. DELL U2720Q", Width: 2560, Height: 1440, RefreshRate: 60, Scale: 1, X: 1920}
+
+	enableTestLaptop  = "keyword monitor " + hypr.MonitorToConfigString(testLaptop)
+	disableTestLaptop = "keyword monitor eDP-1,disable"
+)
+
+type testApp struct {
+	*App
+	hypr    *hyprtest.Fake
+	lid     *fakeSource[power.LidState]
+	power   *fakeSource[power.State]
+	battery *fakeSource[power.Battery]
+	session *fakeSession
+}
+
+// newTestApp builds an App the way RunListener does, on a fake Hyprland with the given monitors
+// and a sysfs tree where eDP-1 is the internal panel.
+func newTestApp(t *testing.T, cfg Config, lid power.LidState, monitors ...hypr.Monitor) *testApp {
+	t.Helper()
+
+	root := t.TempDir()
+	writeConnector(t, root, "card1-eDP-1", "connected")
+	writeConnector(t, root, "card1-DP-1", "connected")
+
+	ta := &testApp{
+		hypr:    hyprtest.New(monitors...),
+		lid:     newFakeSource(lid),
+		power:   newFakeSource(power.StateOnAC),
+		battery: newFakeSource(power.Battery{}),
+		session: &fakeSession{},
+	}
+
+	l, err := newListener(listenerParams{
+		hyprSockConn:    ta.hypr.Events(),
+		lidSource:       ta.lid,
+		powerSource:     ta.power,
+		batterySource:   ta.battery,
+		docks:           cfg.Docks,
+		watchConnectors: cfg.WatchConnectors,
+		sysRoot:         root,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := getInitialState(context.Background(), initialStateParams{
+		laptopMonitorName: cfg.Laptop,
+		hyprClient:        ta.hypr,
+		lidSource:         ta.lid,
+		powerSource:       ta.power,
+		batterySource:     ta.battery,
+		docks:             cfg.Docks,
+		watchConnectors:   cfg.WatchConnectors,
+		sysRoot:           root,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ta.App = newApp(cfg, ta.hypr, l, s)
+	ta.sleeper = ta.session
+	return ta
+}
+
+func disabled(m hypr.Monitor) hypr.Monitor {
+	m.Disabled = true
+	return m
+}
+
+func at(m hypr.Monitor, x, y int64) hypr.Monitor {
+	m.X, m.Y = x, y
+	return m
+}
+
+func TestRunUpdater(t *testing.T) {
+	ran := filepath.Join(t.TempDir(), "ran")
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		lid        power.LidState
+		idle       bool
+		monitors   []hypr.Monitor
+		wantStatus status
+		want       []string
+		wantSleeps []power.SleepAction
+		wantLocks  int
+		wantRan    bool
+	}{
+		{
+			name:       "only laptop, opened",
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{testLaptop},
+			wantStatus: statusOnlyLaptopOpened,
+		},
+		{
+			name:       "only laptop, opened, laptop disabled",
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{disabled(testLaptop)},
+			wantStatus: statusOnlyLaptopOpened,
+			want:       []string{enableTestLaptop},
+		},
+		{
+			name:       "only laptop, closed after undocking",
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{disabled(testLaptop)},
+			wantStatus: statusOnlyLaptopClosed,
+			want:       []string{enableTestLaptop},
+		},
+		{
+			name:       "only laptop, closed, suspend",
+			cfg:        Config{SuspendClosed: true, SuspendClosedAction: string(power.SleepHibernate)},
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{testLaptop},
+			wantStatus: statusOnlyLaptopClosed,
+			wantSleeps: []power.SleepAction{power.SleepHibernate},
+		},
+		{
+			name:       "docked, opened, laptop disabled",
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{disabled(testLaptop), testExternal},
+			wantStatus: statusDockedOpened,
+			want:       []string{enableTestLaptop},
+		},
+		{
+			name:       "docked, closed",
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{testLaptop, testExternal},
+			wantStatus: statusDockedClosed,
+			want:       []string{disableTestLaptop},
+		},
+		{
+			name:       "docked, closed, dpms",
+			cfg:        Config{ClosedStrategy: string(closedStrategyDPMS)},
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{testLaptop, testExternal},
+			wantStatus: statusDockedClosed,
+			want:       []string{"dispatch dpms off eDP-1"},
+		},
+		{
+			name:       "docked, closed, offset",
+			cfg:        Config{ClosedStrategy: string(closedStrategyOffset)},
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{testLaptop, testExternal},
+			wantStatus: statusDockedClosed,
+			want: []string{"keyword monitor " + hypr.MonitorToConfigString(hypr.Monitor{
+				Name: "eDP-1", Width: 1920, Height: 1200, RefreshRate: 60, Scale: 1, X: offsetPosition, Y: offsetPosition,
+			})},
+		},
+		{
+			name:       "docked, closed, laptop already disabled",
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{disabled(testLaptop), testExternal},
+			wantStatus: statusDockedClosed,
+		},
+		{
+			name:       "idle, docked, closed",
+			lid:        power.LidStateClosed,
+			idle:       true,
+			monitors:   []hypr.Monitor{disabled(testLaptop), testExternal},
+			wantStatus: statusDockedClosed,
+			want:       []string{enableTestLaptop},
+		},
+		{
+			name: "configured rule",
+			cfg: Config{Rules: []Rule{{
+				Name:     "desk",
+				Lid:      string(power.LidStateClosed),
+				Monitors: []string{"Dell Inc. *"},
+				Actions: []RuleAction{
+					{Action: actionLaptopOff},
+					{Action: actionPosition, Monitor: "DP-1", Position: "0x0"},
+				},
+			}}},
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{testLaptop, testExternal},
+			wantStatus: statusDockedClosed,
+			want:       []string{disableTestLaptop, "keyword monitor " + hypr.MonitorToConfigString(at(testExternal, 0, 0))},
+		},
+		{
+			name: "configured rules before the defaults",
+			cfg: Config{Rules: []Rule{
+				{Name: "first", Lid: string(power.LidStateOpened), Actions: []RuleAction{{Action: actionDPMS, Monitor: "DP-1", State: "on"}}},
+				{Name: "second", Lid: string(power.LidStateOpened), Actions: []RuleAction{{Action: actionDisable, Monitor: "DP-1"}}},
+			}},
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{disabled(testLaptop), testExternal},
+			wantStatus: statusDockedOpened,
+			want:       []string{"dispatch dpms on DP-1"},
+		},
+		{
+			name: "configured rule not matching",
+			cfg: Config{Rules: []Rule{{
+				Power:   string(power.StateOnBattery),
+				Actions: []RuleAction{{Action: actionDisable, Monitor: "DP-1"}},
+			}}},
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{disabled(testLaptop), testExternal},
+			wantStatus: statusDockedOpened,
+			want:       []string{enableTestLaptop},
+		},
+		{
+			name: "enable and disable actions",
+			cfg: Config{Rules: []Rule{{Actions: []RuleAction{
+				{Action: actionEnable, Monitor: "Dell Inc. *", Position: "1920x0"},
+				{Action: actionDisable, Monitor: "eDP-1"},
+			}}}},
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{testLaptop, at(disabled(testExternal), 0, 0)},
+			wantStatus: statusOnlyLaptopOpened,
+			want: []string{
+				"keyword monitor " + hypr.MonitorToConfigString(at(testExternal, 1920, 0)),
+				disableTestLaptop,
+			},
+		},
+		{
+			name:       "dpms action",
+			cfg:        Config{Rules: []Rule{{Actions: []RuleAction{{Action: actionDPMS, Monitor: "DP-1"}}}}},
+			lid:        power.LidStateOpened,
+			monitors:   []hypr.Monitor{testLaptop, testExternal},
+			wantStatus: statusDockedOpened,
+			want:       []string{"dispatch dpms off DP-1"},
+		},
+		{
+			name: "run, lock and suspend actions",
+			cfg: Config{Rules: []Rule{{Actions: []RuleAction{
+				{Action: actionRun, Command: "touch " + ran},
+				{Action: actionLock},
+				{Action: actionSuspend},
+				{Action: actionSuspend, Sleep: string(power.SleepHibernate)},
+			}}}},
+			lid:        power.LidStateClosed,
+			monitors:   []hypr.Monitor{testLaptop},
+			wantStatus: statusOnlyLaptopClosed,
+			wantSleeps: []power.SleepAction{power.SleepSuspend, power.SleepHibernate},
+			wantLocks:  1,
+			wantRan:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, tt.cfg, tt.lid, tt.monitors...)
+			if tt.idle {
+				a.mode = modeIdle
+			}
+
+			a.refreshState(context.Background())
+			if got := a.status(); got != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", got.string(), tt.wantStatus.string())
+			}
+
+			if _, err := a.runUpdater(); err != nil {
+				t.Fatalf("runUpdater: %v", err)
+			}
+
+			if got := a.hypr.Applied(); !slices.Equal(got, tt.want) {
+				t.Errorf("applied = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(a.session.sleeps, tt.wantSleeps) {
+				t.Errorf("sleeps = %v, want %v", a.session.sleeps, tt.wantSleeps)
+			}
+			if a.session.locks != tt.wantLocks {
+				t.Errorf("locks = %d, want %d", a.session.locks, tt.wantLocks)
+			}
+			if _, err := os.Stat(ran); (err == nil) != tt.wantRan {
+				t.Errorf("run action ran = %v, want %v", err == nil, tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestRefreshStateConnectors(t *testing.T) {
+	a := newTestApp(t, Config{WatchConnectors: true}, power.LidStateClosed, disabled(testLaptop), testExternal)
+	a.refreshState(context.Background())
+	if got := a.status(); got != statusDockedClosed {
+		t.Fatalf("status = %s, want %s", got.string(), statusDockedClosed.string())
+	}
+
+	// the external was unplugged, but Hyprland missed it
+	writeConnector(t, a.listener.sysRoot, "card1-DP-1", "disconnected")
+	a.refreshState(context.Background())
+	if got := a.status(); got != statusOnlyLaptopClosed {
+		t.Fatalf("status = %s, want %s", got.string(), statusOnlyLaptopClosed.string())
+	}
+
+	if _, err := a.runUpdater(); err != nil {
+		t.Fatalf("runUpdater: %v", err)
+	}
+	if got, want := a.hypr.Applied(), []string{enableTestLaptop}; !slices.Equal(got, want) {
+		t.Errorf("applied = %q, want %q", got, want)
+	}
+
+	// without a connector to go by, Hyprland's view is used
+	writeConnector(t, a.listener.sysRoot, "card1-eDP-1", "disconnected")
+	a.refreshState(context.Background())
+	if got := a.status(); got != statusDockedClosed {
+		t.Errorf("status = %s, want %s", got.string(), statusDockedClosed.string())
+	}
+}
+
+// waitApplied waits until the fake has applied want commands in total.
+func waitApplied(t *testing.T, f *hyprtest.Fake, want []string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := f.Applied(); len(got) >= len(want) {
+			if !slices.Equal(got, want) {
+				t.Fatalf("applied = %q, want %q", got, want)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("applied = %q, want %q", f.Applied(), want)
+}
+
+// runListenAndHandle starts a's event loop with a short settle window and stops it at the end of
+// the test.
+func runListenAndHandle(t *testing.T, a *testApp) {
+	t.Helper()
+
+	// the command socket lives in the temp dir
+	t.Setenv("TMPDIR", t.TempDir())
+	a.Config.SettleWindow = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.listenAndHandle(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("listenAndHandle didn't stop")
+		}
+	})
+}
+
+func TestListenAndHandleLidAndDock(t *testing.T) {
+	a := newTestApp(t, Config{}, power.LidStateOpened, testLaptop, testExternal)
+	runListenAndHandle(t, a)
+
+	// closing the lid while docked takes the laptop display out of use
+	a.lid.set(power.LidStateClosed)
+	waitApplied(t, a.hypr, []string{disableTestLaptop})
+
+	// unplugging the external with the lid still closed brings it back
+	a.hypr.Disconnect(testExternal.Name)
+	waitApplied(t, a.hypr, []string{disableTestLaptop, enableTestLaptop})
+
+	// plugging it back in with the lid closed turns the laptop display off again
+	a.hypr.Connect(testExternal)
+	waitApplied(t, a.hypr, []string{disableTestLaptop, enableTestLaptop, disableTestLaptop})
+}
+
+func TestListenAndHandleSleep(t *testing.T) {
+	a := newTestApp(t, Config{}, power.LidStateClosed, disabled(testLaptop), testExternal)
+	sleep := &fakeSleepSource{changes: make(chan bool, 1)}
+	inh := &fakeInhibitor{held: true}
+	a.listener.sleepSource = sleep
+	a.listener.sleepInhibitor = inh
+
+	// the idle command doesn't keep sleep from being handled
+	a.mode = modeIdle
+	runListenAndHandle(t, a)
+
+	// before sleeping, the laptop display is enabled so the system wakes up to a usable screen
+	sleep.changes <- true
+	waitApplied(t, a.hypr, []string{enableTestLaptop})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for inh.releases() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("sleep inhibitor not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestListenAndHandleHyprReconnect(t *testing.T) {
+	tests := []struct {
+		name     string
+		idle     bool
+		lid      power.LidState
+		monitors []hypr.Monitor
+		closeLid bool // while Hyprland is down, so no event reports it
+		want     []string
+	}{
+		{
+			name:     "normal mode",
+			lid:      power.LidStateOpened,
+			monitors: []hypr.Monitor{testLaptop, testExternal},
+			closeLid: true,
+			want:     []string{disableTestLaptop},
+		},
+		{
+			// only the idle rule matches, which keeps the laptop display on
+			name:     "idle mode",
+			idle:     true,
+			lid:      power.LidStateClosed,
+			monitors: []hypr.Monitor{disabled(testLaptop), testExternal},
+			want:     []string{enableTestLaptop},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, Config{}, tt.lid, tt.monitors...)
+			if tt.idle {
+				a.mode = modeIdle
+			}
+			runListenAndHandle(t, a)
+
+			if tt.closeLid {
+				a.lid.mu.Lock()
+				a.lid.val = power.LidStateClosed
+				a.lid.mu.Unlock()
+			}
+
+			a.hypr.Restart()
+			waitApplied(t, a.hypr, tt.want)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+// BatteryPolicy sets what hyprdocked does when the battery runs low. The battery is low when it
+// is discharging and at or below LowPercent, or has LowMinutes or less remaining.
+type BatteryPolicy struct {
+	LowPercent     float64 `mapstructure:"low-percent"`
+	LowMinutes     int     `mapstructure:"low-minutes"`
+	ClosedAction   string  `mapstructure:"closed-action"`    // sleep action to take when the lid is closed and battery is low
+	LowRefreshRate float64 `mapstructure:"low-refresh-rate"` // laptop display refresh rate while battery is low
+}
+
+func (p BatteryPolicy) enabled() bool {
+	return p.LowPercent > 0 || p.LowMinutes > 0
+}
+
+// batteryLow reports whether the battery is low according to the configured policy.
+func (a *App) batteryLow() bool {
+	p := a.Config.Battery
+	b := a.battery
+	if !p.enabled() || !b.Present || b.State != power.BatteryStateDischarging {
+		return false
+	}
+
+	if p.LowPercent > 0 && b.Percentage <= p.LowPercent {
+		return true
+	}
+
+	// TimeToEmpty is 0 while UPower is still estimating
+	return p.LowMinutes > 0 && b.TimeToEmpty > 0 && b.TimeToEmpty <= time.Duration(p.LowMinutes)*time.Minute
+}
+
+// refreshBattery re-reads the battery and reports whether it crossed a low battery threshold.
+// Battery properties change constantly, so only crossings are worth a full update.
+func (a *App) refreshBattery(ctx context.Context) bool {
+	b, err := a.listener.batterySource.GetCurrentState(ctx)
+	if err != nil {
+		slog.Error("refreshing battery state", "error", err)
+		return false
+	}
+
+	wasLow := a.batteryLow()
+	a.battery = b
+	isLow := a.batteryLow()
+	if wasLow != isLow {
+		slog.Info("battery low state changed", "low", isLow, "percentage", b.Percentage, "time_to_empty", b.TimeToEmpty)
+	}
+
+	return wasLow != isLow
+}
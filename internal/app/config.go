@@ -1,6 +1,7 @@
 package app
 
 import (
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -10,19 +11,75 @@ import (
 
 const configReloadDelay = 100 * time.Millisecond
 
+// closedStrategy is how the laptop display is taken out of use when docked with the lid closed.
+type closedStrategy string
+
+const (
+	closedStrategyDisable closedStrategy = "disable" // disable the monitor (default)
+	closedStrategyDPMS    closedStrategy = "dpms"    // turn the output off but keep it in the layout
+	closedStrategyOffset  closedStrategy = "offset"  // keep it on, moved far away from the externals
+
+	// offsetPosition is the X and Y the laptop display is moved to by the offset strategy.
+	offsetPosition int64 = 32768
+)
+
 type Config struct {
-	Debug           bool       `mapstructure:"debug"`
-	Laptop          string     `mapstructure:"laptop"`
-	SuspendIdle     bool       `mapstructure:"suspend-idle"`
-	SuspendClosed   bool       `mapstructure:"suspend-closed"`
-	PostUpdateHooks []PostHook `mapstructure:"post-hooks"`
-	SequentialHooks bool       `mapstructure:"sequential-hooks"`
-	SettleWindow    int        `mapstructure:"settle-window"`
+	Debug                    bool                      `mapstructure:"debug"`
+	Laptop                   string                    `mapstructure:"laptop"`
+	LaptopMatch              string                    `mapstructure:"laptop-match"` // regex matched against monitor names and descriptions
+	SuspendIdle              bool                      `mapstructure:"suspend-idle"`
+	SuspendClosed            bool                      `mapstructure:"suspend-closed"`
+	SuspendClosedBatteryOnly bool                      `mapstructure:"suspend-closed-battery-only"`
+	SuspendIdleAction        string                    `mapstructure:"suspend-idle-action"`
+	SuspendClosedAction      string                    `mapstructure:"suspend-closed-action"`
+	PostUpdateHooks          []PostHook                `mapstructure:"post-hooks"`
+	SequentialHooks          bool                      `mapstructure:"sequential-hooks"`
+	SettleWindow             int                       `mapstructure:"settle-window"`
+	MigrateWorkspaces        bool                      `mapstructure:"migrate-workspaces"`
+	WorkspaceTarget          string                    `mapstructure:"workspace-target"`
+	ClosedStrategy           string                    `mapstructure:"closed-strategy"`
+	Battery                  BatteryPolicy             `mapstructure:"battery"`
+	LogindSleep              bool                      `mapstructure:"logind-sleep"`
+	LidBackend               string                    `mapstructure:"lid-backend"`
+	LidDevice                string                    `mapstructure:"lid-device"`
+	Docks                    []DockDevice              `mapstructure:"docks"`
+	WatchConnectors          bool                      `mapstructure:"watch-connectors"`
+	PowerProfiles            []PowerProfile            `mapstructure:"power-profiles"`
+	Rules                    []Rule                    `mapstructure:"rules"`
+	DisplayProfiles          []DisplayProfile          `mapstructure:"display-profiles"`
+	LaptopPlacement          LaptopPlacement           `mapstructure:"laptop-placement"`
+	Mirror                   MirrorPolicy              `mapstructure:"mirror"`
+	LaptopOverrides          map[string]LaptopOverride `mapstructure:"laptop-overrides"` // keyed by status
+	Panels                   []InternalPanel           `mapstructure:"panels"`
 }
 
 type PostHook struct {
 	Command        string `mapstructure:"command"`
 	OnStatusChange bool   `mapstructure:"on-status-change"`
+	Power          string `mapstructure:"power"` // only run on "ac" or "battery"; empty runs on both
+}
+
+// closedStrategy returns the configured closed strategy, falling back to disable if it is empty
+// or unknown.
+func (c Config) closedStrategy() closedStrategy {
+	switch cs := closedStrategy(c.ClosedStrategy); cs {
+	case closedStrategyDisable, closedStrategyDPMS, closedStrategyOffset:
+		return cs
+	case "":
+		return closedStrategyDisable
+	default:
+		slog.Warn("unknown closed strategy; using disable", "closed_strategy", c.ClosedStrategy)
+		return closedStrategyDisable
+	}
+}
+
+// Validate reports config values that are set but can't be used.
+func (c Config) Validate() error {
+	if err := validateRules(c.Rules); err != nil {
+		return fmt.Errorf("rules: %w", err)
+	}
+
+	return nil
 }
 
 // onConfigChange handles live updates when a config file change is detected.
@@ -37,6 +94,10 @@ func (a *App) onConfigChange(e fsnotify.Event) {
 			slog.Error("reloading config", "error", err)
 			return
 		}
+		if err := newCfg.Validate(); err != nil {
+			slog.Error("reloading config; keeping the current config", "error", err)
+			return
+		}
 		select {
 		case a.listener.configCh <- newCfg:
 			slog.Info("config reloaded", "config", newCfg)
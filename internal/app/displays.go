@@ -0,0 +1,201 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+// DisplayProfile is a named layout for one set of external monitors. It matches when every
+// connected external monitor is matched by exactly one of its monitors, and vice versa.
+type DisplayProfile struct {
+	Name     string           `mapstructure:"name"`
+	Monitors []ProfileMonitor `mapstructure:"monitors"`
+	Laptop   *ProfileMonitor  `mapstructure:"laptop"` // laptop display settings while this profile is active
+}
+
+// ProfileMonitor is the settings of one monitor in a display profile. Empty fields keep what
+// Hyprland currently has.
+type ProfileMonitor struct {
+	Match     string  `mapstructure:"match"`    // description pattern, or monitor name
+	Mode      string  `mapstructure:"mode"`     // "<width>x<height>[@<refresh>]"
+	Position  string  `mapstructure:"position"` // "<x>x<y>"
+	Scale     float64 `mapstructure:"scale"`
+	Transform *int    `mapstructure:"transform"` // nil keeps the current transform; 0 resets it
+	Disabled  bool    `mapstructure:"disabled"`
+}
+
+// apply returns m with the profile settings applied.
+func (pm ProfileMonitor) apply(m hypr.Monitor) (hypr.Monitor, error) {
+	if pm.Mode != "" {
+		w, h, r, err := hypr.ParseMode(pm.Mode)
+		if err != nil {
+			return m, fmt.Errorf("invalid mode %q: %w", pm.Mode, err)
+		}
+		m.Width, m.Height = w, h
+		if r > 0 {
+			m.RefreshRate = r
+		}
+	}
+
+	if pm.Position != "" {
+		x, y, err := parsePosition(pm.Position)
+		if err != nil {
+			return m, fmt.Errorf("invalid position: %w", err)
+		}
+		m.X, m.Y = x, y
+	}
+
+	if pm.Scale > 0 {
+		m.Scale = pm.Scale
+	}
+	if pm.Transform != nil {
+		m.Transform = *pm.Transform
+	}
+
+	return m, nil
+}
+
+func (pm ProfileMonitor) matches(m hypr.Monitor) bool {
+	return m.Name == pm.Match || globMatch(pm.Match, m.Description)
+}
+
+// specificity ranks how narrowly a profile matches: literal characters count, wildcards don't.
+func (p DisplayProfile) specificity() int {
+	n := 0
+	for _, pm := range p.Monitors {
+		n += len(pm.Match) - strings.Count(pm.Match, "*") - strings.Count(pm.Match, "?")
+	}
+
+	return n
+}
+
+// assign pairs each of externals with a distinct profile monitor, returning the profile monitor
+// index for each external, or false if the sets don't match exactly.
+func (p DisplayProfile) assign(externals []hypr.Monitor) ([]int, bool) {
+	if len(p.Monitors) != len(externals) {
+		return nil, false
+	}
+
+	used := make([]bool, len(p.Monitors))
+	pairs := make([]int, len(externals))
+	var try func(i int) bool
+	try = func(i int) bool {
+		if i == len(externals) {
+			return true
+		}
+		for j, pm := range p.Monitors {
+			if used[j] || !pm.matches(externals[i]) {
+				continue
+			}
+			used[j], pairs[i] = true, j
+			if try(i + 1) {
+				return true
+			}
+			used[j] = false
+		}
+		return false
+	}
+
+	return pairs, try(0)
+}
+
+// externalDisplays returns every known monitor other than the internal panels.
+func (s *state) externalDisplays() []hypr.Monitor {
+	var ms []hypr.Monitor
+	for _, m := range s.allDisplays {
+		if !s.isPanel(m.Name) {
+			ms = append(ms, m)
+		}
+	}
+
+	return ms
+}
+
+// checkMonitorSet records the connected external monitors and, if they changed, marks the
+// display profile to be re-applied on the next update.
+func (a *App) checkMonitorSet() {
+	var set []string
+	for _, m := range a.externalDisplays() {
+		set = append(set, m.Description)
+	}
+	slices.Sort(set)
+
+	if slices.Equal(a.monitorSet, set) {
+		return
+	}
+
+	slog.Debug("external monitor set changed", "monitors", set)
+	a.monitorSet = set
+	a.profilePending = true
+}
+
+// matchDisplayProfile returns the most specific profile matching the connected externals, and
+// the profile monitor for each of them.
+func (a *App) matchDisplayProfile() (DisplayProfile, []int, bool) {
+	externals := a.externalDisplays()
+	var (
+		best      DisplayProfile
+		bestPairs []int
+		found     bool
+	)
+	for _, p := range a.Config.DisplayProfiles {
+		pairs, ok := p.assign(externals)
+		if !ok {
+			continue
+		}
+		if !found || p.specificity() > best.specificity() {
+			best, bestPairs, found = p, pairs, true
+		}
+	}
+
+	return best, bestPairs, found
+}
+
+// applyDisplayProfile adds the settings of the best-matching profile to tx if the monitor set
+// changed since it was last applied, and reports whether it did. The caller marks the profile
+// pending again if tx fails to apply.
+func (a *App) applyDisplayProfile(lg *slog.Logger, tx *hypr.Transaction) bool {
+	if !a.profilePending {
+		return false
+	}
+	a.profilePending = false
+
+	p, pairs, ok := a.matchDisplayProfile()
+	if !ok {
+		if a.displayProfile != nil {
+			lg.Info("[UPDATER]no display profile matches connected monitors")
+		}
+		a.displayProfile = nil
+		return false
+	}
+
+	lg.Info("[UPDATER]applying display profile", "profile", p.Name)
+	a.displayProfile = &p
+	for i, m := range a.externalDisplays() {
+		pm := p.Monitors[pairs[i]]
+		if pm.Disabled {
+			if !m.Disabled {
+				tx.DisableMonitor(m)
+			}
+			continue
+		}
+
+		want, err := pm.apply(m)
+		if err != nil {
+			lg.Warn("[UPDATER]invalid display profile monitor", "profile", p.Name, "match", pm.Match, "error", err)
+			continue
+		}
+		tx.EnableOrUpdateMonitor(want)
+	}
+
+	return true
+}
+
+// profileDisablesLaptop reports whether the active display profile keeps the laptop display off.
+func (a *App) profileDisablesLaptop() bool {
+	return a.displayProfile != nil && a.displayProfile.Laptop != nil && a.displayProfile.Laptop.Disabled
+}
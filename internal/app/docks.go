@@ -0,0 +1,188 @@
+package app
+
+import (
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/udev"
+)
+
+// DockDevice identifies a dock by a USB or Thunderbolt device it exposes, so it counts as docked
+// even when none of its displays are active.
+type DockDevice struct {
+	Name        string            `mapstructure:"name"`
+	USB         string            `mapstructure:"usb"`         // idVendor:idProduct in hex, e.g. 17ef:30b4
+	Thunderbolt string            `mapstructure:"thunderbolt"` // vendor:device in hex, e.g. 0x108:0x2031
+	Attrs       map[string]string `mapstructure:"attrs"`       // sysfs attributes that must all match, e.g. device_name
+}
+
+// dockSubsystems are the sysfs attributes read up front for each subsystem docks can be matched in.
+var dockSubsystems = map[string][]string{
+	"usb":         {"idVendor", "idProduct", "manufacturer", "product", "serial"},
+	"thunderbolt": {"vendor", "device", "vendor_name", "device_name", "unique_id"},
+}
+
+func (d DockDevice) matches(dev udev.Device) bool {
+	switch {
+	case d.USB != "":
+		if dev.Subsystem != "usb" || !idsMatch(d.USB, dev.Attr("idVendor"), dev.Attr("idProduct")) {
+			return false
+		}
+	case d.Thunderbolt != "":
+		if dev.Subsystem != "thunderbolt" || !idsMatch(d.Thunderbolt, dev.Attr("vendor"), dev.Attr("device")) {
+			return false
+		}
+	case len(d.Attrs) == 0:
+		return false
+	}
+
+	// configured attributes may be any sysfs file, so they're read as needed
+	for k, v := range d.Attrs {
+		if dev.Attr(k) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// idsMatch compares a "vendor:product" spec against sysfs IDs numerically, since USB reports
+// "17ef" and Thunderbolt "0x108".
+func idsMatch(spec, vendor, product string) bool {
+	sv, sp, ok := strings.Cut(spec, ":")
+	if !ok {
+		return false
+	}
+
+	return hexEqual(sv, vendor) && hexEqual(sp, product)
+}
+
+func hexEqual(a, b string) bool {
+	x, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(a), "0x"), 16, 32)
+	if err != nil {
+		return false
+	}
+
+	y, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(b), "0x"), 16, 32)
+	if err != nil {
+		return false
+	}
+
+	return x == y
+}
+
+// matchDock returns the name of the first configured dock dev matches, or "".
+func matchDock(docks []DockDevice, dev udev.Device) string {
+	for _, d := range docks {
+		if d.matches(dev) {
+			if d.Name != "" {
+				return d.Name
+			}
+			return dev.DevPath
+		}
+	}
+
+	return ""
+}
+
+// scanDocks returns the connected configured docks, keyed by device path.
+func scanDocks(sysRoot string, docks []DockDevice) (map[string]string, error) {
+	found := make(map[string]string)
+	if len(docks) == 0 {
+		return found, nil
+	}
+
+	for sub, attrs := range dockSubsystems {
+		devs, err := udev.ScanSubsystem(sysRoot, sub, attrs...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dev := range devs {
+			if name := matchDock(docks, dev); name != "" {
+				found[dev.DevPath] = name
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// dockNames returns the sorted, unique dock names in a scanDocks result.
+func dockNames(found map[string]string) []string {
+	var names []string
+	for _, n := range found {
+		if !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// dockTracker follows configured dock devices through USB and Thunderbolt uevents.
+type dockTracker struct {
+	sysRoot string
+	config  func() []DockDevice // the current dock config, which can change on reload
+	docks   []DockDevice        // the config tracked was scanned with
+	tracked map[string]string   // device path -> dock name
+}
+
+func newDockTracker(sysRoot string, config func() []DockDevice) (*dockTracker, error) {
+	t := &dockTracker{sysRoot: sysRoot, config: config}
+	if err := t.rescan(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *dockTracker) rescan() error {
+	docks := t.config()
+	tracked, err := scanDocks(t.sysRoot, docks)
+	if err != nil {
+		return err
+	}
+
+	t.docks, t.tracked = docks, tracked
+	return nil
+}
+
+// handle returns an event if ev connected or disconnected a configured dock.
+func (t *dockTracker) handle(ev udev.Event) (listenerEvent, bool) {
+	if _, ok := dockSubsystems[ev.Subsystem]; !ok {
+		return listenerEvent{}, false
+	}
+
+	if !reflect.DeepEqual(t.docks, t.config()) {
+		if err := t.rescan(); err != nil {
+			slog.Error("rescanning docks after config change", "error", err)
+		}
+	}
+
+	var name string
+	switch ev.Action {
+	case "add":
+		dev := udev.ReadDevice(t.sysRoot, ev.Subsystem, ev.DevPath, dockSubsystems[ev.Subsystem]...)
+		if name = matchDock(t.docks, dev); name != "" {
+			t.tracked[ev.DevPath] = name
+		}
+	case "remove":
+		// sysfs is already gone on remove, so only known dock devices can be matched
+		if n, ok := t.tracked[ev.DevPath]; ok {
+			name = n
+			delete(t.tracked, ev.DevPath)
+		}
+	}
+
+	if name == "" {
+		return listenerEvent{}, false
+	}
+
+	slog.Debug("dock device event", "action", ev.Action, "dock", name, "devpath", ev.DevPath)
+	return listenerEvent{Type: dockChangeEvent, Details: ev.Action + " " + name}, true
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/drm"
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/udev"
+)
+
+// connectorTracker follows which DRM connectors have a display physically attached.
+type connectorTracker struct {
+	sysRoot   string
+	connected []string
+}
+
+func newConnectorTracker(sysRoot string) (*connectorTracker, error) {
+	cs, err := drm.ScanConnectors(sysRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connectorTracker{sysRoot: sysRoot, connected: drm.ConnectedNames(cs)}, nil
+}
+
+// handle rescans the connectors on a drm uevent (the kernel sends one "change" per hotplug on the
+// card, without saying which connector) and returns an event if the connected set changed.
+func (t *connectorTracker) handle(ev udev.Event) (listenerEvent, bool) {
+	if ev.Subsystem != "drm" {
+		return listenerEvent{}, false
+	}
+
+	cs, err := drm.ScanConnectors(t.sysRoot)
+	if err != nil {
+		slog.Error("scanning drm connectors", "error", err)
+		return listenerEvent{}, false
+	}
+
+	connected := drm.ConnectedNames(cs)
+	if slices.Equal(t.connected, connected) {
+		return listenerEvent{}, false
+	}
+
+	slog.Debug("drm connectors changed", "connected", connected, "previous", t.connected)
+	t.connected = connected
+	return listenerEvent{Type: connectorChangeEvent, Details: ev.DevPath}, true
+}
+
+// listenUdevEvents reads kernel uevents and passes them to the dock and connector trackers. Both
+// share one netlink socket, since each message is only delivered to one reader.
+func (l *listener) listenUdevEvents(ctx context.Context, events chan<- listenerEvent) error {
+	var (
+		docks      *dockTracker
+		connectors *connectorTracker
+		subsystems []string
+		err        error
+	)
+
+	// docks are tracked even if none are configured yet, since a config reload can add some
+	if docks, err = newDockTracker(l.sysRoot, l.dockConfig); err != nil {
+		return err
+	}
+	for sub := range dockSubsystems {
+		subsystems = append(subsystems, sub)
+	}
+
+	if l.watchConnectors {
+		if connectors, err = newConnectorTracker(l.sysRoot); err != nil {
+			return err
+		}
+		subsystems = append(subsystems, "drm")
+	}
+
+	uevents := make(chan udev.Event, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- l.udevMonitor.Listen(ctx, uevents, subsystems...)
+	}()
+
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case uev := <-uevents:
+			var (
+				ev listenerEvent
+				ok bool
+			)
+			if docks != nil {
+				ev, ok = docks.handle(uev)
+			}
+			if !ok && connectors != nil {
+				ev, ok = connectors.handle(uev)
+			}
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// crossCheckConnectors scans the DRM connectors under sysRoot, logs where Hyprland's view of the
+// monitors disagrees with the ones the kernel reports as physically connected, and returns the
+// connected names, or nil if there are none to go by.
+func crossCheckConnectors(sysRoot string, displays []hypr.Monitor) ([]string, error) {
+	cs, err := drm.ScanConnectors(sysRoot)
+	if err != nil {
+		return nil, err
+	}
+	connected := drm.ConnectedNames(cs)
+
+	for _, name := range connected {
+		if !slices.ContainsFunc(displays, func(m hypr.Monitor) bool { return m.Name == name }) {
+			slog.Warn("display connected but unknown to hyprland", "connector", name)
+		}
+	}
+
+	for _, m := range displays {
+		if !m.Disabled && !slices.Contains(connected, m.Name) && isPhysicalConnector(m.Name) {
+			slog.Warn("hyprland reports a display the kernel says is disconnected", "monitor", m.Name)
+		}
+	}
+
+	return connected, nil
+}
+
+// physicallyConnected reports whether the kernel agrees the display named name is connected.
+// Virtual outputs, and any display while the connectors are unknown, are taken at Hyprland's word.
+func (s *state) physicallyConnected(name string) bool {
+	return s.connectors == nil || !isPhysicalConnector(name) || slices.Contains(s.connectors, name)
+}
+
+// isPhysicalConnector filters out virtual outputs (headless, nested Wayland) that never appear
+// in DRM sysfs.
+func isPhysicalConnector(name string) bool {
+	return !slices.ContainsFunc([]string{"HEADLESS-", "WL-", "X11-"}, func(p string) bool {
+		return strings.HasPrefix(name, p)
+	})
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/udev"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeConnector adds <sysRoot>/class/drm/<dir> with the given status.
+func writeConnector(t *testing.T, sysRoot, dir, status string) {
+	t.Helper()
+	writeFile(t, filepath.Join(sysRoot, "class", "drm", dir, "status"), status+"\n")
+}
+
+func TestConnectorTrackerHandle(t *testing.T) {
+	root := t.TempDir()
+	writeConnector(t, root, "card1-eDP-1", "connected")
+	writeConnector(t, root, "card1-HDMI-A-1", "disconnected")
+
+	tr, err := newConnectorTracker(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"eDP-1"}; !slices.Equal(tr.connected, want) {
+		t.Fatalf("connected = %v, want %v", tr.connected, want)
+	}
+
+	change := udev.Event{Action: "change", Subsystem: "drm", DevPath: "/devices/pci0000:00/0000:00:02.0/drm/card1"}
+
+	if _, ok := tr.handle(change); ok {
+		t.Error("event without a connector change")
+	}
+
+	if _, ok := tr.handle(udev.Event{Action: "add", Subsystem: "usb", DevPath: "/devices/usb3/3-1"}); ok {
+		t.Error("event for a usb uevent")
+	}
+
+	writeConnector(t, root, "card1-HDMI-A-1", "connected")
+	ev, ok := tr.handle(change)
+	if !ok {
+		t.Fatal("no event after HDMI-A-1 connected")
+	}
+	if ev.Type != connectorChangeEvent || ev.Details != change.DevPath {
+		t.Errorf("event = %+v", ev)
+	}
+	if want := []string{"HDMI-A-1", "eDP-1"}; !slices.Equal(tr.connected, want) {
+		t.Errorf("connected = %v, want %v", tr.connected, want)
+	}
+
+	writeConnector(t, root, "card1-HDMI-A-1", "disconnected")
+	if _, ok := tr.handle(change); !ok {
+		t.Error("no event after HDMI-A-1 disconnected")
+	}
+	if want := []string{"eDP-1"}; !slices.Equal(tr.connected, want) {
+		t.Errorf("connected = %v, want %v", tr.connected, want)
+	}
+}
+
+func TestCrossCheckConnectors(t *testing.T) {
+	root := t.TempDir()
+	writeConnector(t, root, "card1-eDP-1", "connected")
+	writeConnector(t, root, "card1-DP-1", "connected")
+	writeConnector(t, root, "card1-DP-2", "disconnected")
+
+	displays := []hypr.Monitor{{Name: "eDP-1"}, {Name: "DP-2"}, {Name: "HEADLESS-1"}}
+	got, err := crossCheckConnectors(root, displays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"DP-1", "eDP-1"}; !slices.Equal(got, want) {
+		t.Errorf("connected = %v, want %v", got, want)
+	}
+
+	got, err = crossCheckConnectors(filepath.Join(root, "missing"), displays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("connected without a drm tree = %v", got)
+	}
+}
+
+func TestIsPhysicalConnector(t *testing.T) {
+	tests := map[string]bool{
+		"eDP-1":      true,
+		"HDMI-A-1":   true,
+		"DP-3":       true,
+		"HEADLESS-2": false,
+		"WL-1":       false,
+		"X11-1":      false,
+	}
+
+	for name, want := range tests {
+		if got := isPhysicalConnector(name); got != want {
+			t.Errorf("isPhysicalConnector(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+// desiredLaptop returns the laptop display settings to apply along with the changes in tx.
+func (a *App) desiredLaptop(tx *hypr.Transaction) hypr.Monitor {
+	m := a.laptopDisplay
+	if o, ok := a.laptopOverride(); ok {
+		if om, err := o.apply(m); err == nil {
+			m = om
+		} else {
+			slog.Warn("invalid laptop override", "status", a.statusString(), "error", err)
+		}
+	}
+
+	profiled := false
+	if p := a.displayProfile; p != nil && p.Laptop != nil && !p.Laptop.Disabled {
+		if pm, err := p.Laptop.apply(m); err == nil {
+			m = pm
+			profiled = p.Laptop.Position != ""
+		} else {
+			slog.Warn("invalid display profile laptop settings", "profile", p.Name, "error", err)
+		}
+	}
+
+	// a position set by the display profile wins over automatic placement
+	if !profiled {
+		m, _ = a.placeLaptop(m, tx)
+	}
+
+	if mm := a.mirrorMode; mm != nil {
+		m.Width, m.Height, m.RefreshRate = mm.width, mm.height, mm.refresh
+	}
+
+	if rr := a.Config.Battery.LowRefreshRate; rr > 0 && a.batteryLow() {
+		m.RefreshRate = closestRefreshRate(m, rr)
+	}
+
+	return m
+}
+
+// laptopNeedsUpdate reports whether the enabled laptop display differs from the desired settings.
+func (a *App) laptopNeedsUpdate(tx *hypr.Transaction) bool {
+	cur, ok := a.currentLaptop()
+	if !ok || cur.Disabled {
+		return false
+	}
+
+	want := a.desiredLaptop(tx)
+	if cur.Width != want.Width || cur.Height != want.Height || math.Abs(cur.RefreshRate-want.RefreshRate) > 0.5 {
+		return true
+	}
+
+	// with overrides, another status may have changed these, so they are restored too
+	if len(a.Config.LaptopOverrides) > 0 && (cur.Transform != want.Transform || math.Abs(cur.Scale-want.Scale) > 0.01) {
+		return true
+	}
+
+	// placement is only enforced when a display profile or the placement config sets it
+	if p := a.displayProfile; (p != nil && p.Laptop != nil) || a.Config.LaptopPlacement.enabled() {
+		return cur.X != want.X || cur.Y != want.Y || cur.Transform != want.Transform ||
+			math.Abs(cur.Scale-want.Scale) > 0.01
+	}
+
+	return false
+}
+
+// closestRefreshRate picks the refresh rate among m's available modes at its current resolution
+// that is nearest to target. If no modes are known, target is used as-is.
+func closestRefreshRate(m hypr.Monitor, target float64) float64 {
+	best := target
+	bestDiff := math.Inf(1)
+	for _, mode := range m.AvailableModes {
+		w, h, r, err := hypr.ParseMode(strings.TrimSpace(mode))
+		if err != nil || w != m.Width || h != m.Height {
+			continue
+		}
+
+		if d := math.Abs(r - target); d < bestDiff {
+			best, bestDiff = r, d
+		}
+	}
+
+	return best
+}
+
+// bestRefreshRate returns the highest refresh rate m supports at the given resolution.
+func bestRefreshRate(m hypr.Monitor, width, height int64) float64 {
+	var best float64
+	for _, mode := range m.AvailableModes {
+		w, h, r, err := hypr.ParseMode(strings.TrimSpace(mode))
+		if err == nil && w == width && h == height && r > best {
+			best = r
+		}
+	}
+
+	return best
+}
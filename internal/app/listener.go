@@ -10,49 +10,94 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dsrosen6/hyprdocked/internal/hypr"
 	"github.com/dsrosen6/hyprdocked/internal/power"
+	"github.com/dsrosen6/hyprdocked/internal/udev"
 	"github.com/godbus/dbus/v5"
 )
 
 type (
 	listener struct {
-		hctlSocketConn *hypr.SocketConn
-		lidHandler     *power.LidHandler
-		configCh       chan Config
+		hctlSocketConn  hypr.EventSource
+		lidSource       power.LidSource
+		powerSource     power.Source
+		batterySource   power.BatterySource
+		sleepSource     power.SleepSource // nil if logind sleep integration is disabled
+		sleepInhibitor  power.Inhibitor   // nil if logind sleep integration is disabled
+		udevMonitor     *udev.Monitor     // nil if neither docks nor connectors are watched
+		watchConnectors bool
+		sysRoot         string
+
+		docksMu  sync.Mutex
+		docks    []DockDevice // shared with the udev goroutine; use dockConfig/setDocks
+		configCh chan Config
 	}
 
 	listenerEvent struct {
 		Type    eventType
 		Details string
+		Display *displayEvent // set for v2 display events
 		Done    chan error
 	}
 
+	// displayEvent is the monitor carried by a monitoraddedv2/monitorremovedv2 event.
+	displayEvent struct {
+		ID          int64
+		Name        string
+		Description string
+	}
+
 	listenerParams struct {
-		hyprSockConn *hypr.SocketConn
-		lidHandler   *power.LidHandler
-		dbusConn     *dbus.Conn
+		hyprSockConn    hypr.EventSource
+		lidSource       power.LidSource
+		powerSource     power.Source
+		batterySource   power.BatterySource
+		sleepSource     power.SleepSource
+		sleepInhibitor  power.Inhibitor
+		udevMonitor     *udev.Monitor
+		docks           []DockDevice
+		watchConnectors bool
+		sysRoot         string
+		dbusConn        *dbus.Conn
 	}
 
 	eventType string
 )
 
-var displayEvents = map[string]eventType{
-	"monitoradded":   displayAddEvent,
-	"monitorremoved": displayRemoveEvent,
-}
+var (
+	displayEvents = map[string]eventType{
+		"monitoradded":   displayAddEvent,
+		"monitorremoved": displayRemoveEvent,
+	}
+
+	// displayEventsV2 carry "ID,NAME,DESCRIPTION". Hyprland sends them alongside the v1 events.
+	displayEventsV2 = map[string]eventType{
+		"monitoraddedv2":   displayAddEvent,
+		"monitorremovedv2": displayRemoveEvent,
+	}
+)
 
 const (
-	displayAddEvent     eventType = "DISPLAY_ADDED"
-	displayRemoveEvent  eventType = "DISPLAY_REMOVED"
-	displayUnknownEvent eventType = "DISLAY_UNKNOWN_EVENT"
-	lidSwitchEvent      eventType = "LID_SWITCH"
-	idleCmdEvent        eventType = "IDLE_CMD"
-	resumeCmdEvent      eventType = "RESUME_CMD"
-	pingCmdEvent        eventType = "PING_CMD"
+	displayAddEvent      eventType = "DISPLAY_ADDED"
+	displayRemoveEvent   eventType = "DISPLAY_REMOVED"
+	displayUnknownEvent  eventType = "DISLAY_UNKNOWN_EVENT"
+	hyprReconnectEvent   eventType = "HYPR_RECONNECTED"
+	lidSwitchEvent       eventType = "LID_SWITCH"
+	powerChangeEvent     eventType = "POWER_CHANGE"
+	batteryChangeEvent   eventType = "BATTERY_CHANGE"
+	sleepEvent           eventType = "SLEEP"
+	wakeEvent            eventType = "WAKE"
+	dockChangeEvent      eventType = "DOCK_CHANGE"
+	connectorChangeEvent eventType = "CONNECTOR_CHANGE"
+	idleCmdEvent         eventType = "IDLE_CMD"
+	resumeCmdEvent       eventType = "RESUME_CMD"
+	pingCmdEvent         eventType = "PING_CMD"
 
 	cmdSockName         = "hyprdocked.sock"
 	defaultSettleWindow = 3
@@ -60,12 +105,34 @@ const (
 
 func newListener(p listenerParams) (*listener, error) {
 	return &listener{
-		hctlSocketConn: p.hyprSockConn,
-		lidHandler:     p.lidHandler,
-		configCh:       make(chan Config, 1),
+		hctlSocketConn:  p.hyprSockConn,
+		lidSource:       p.lidSource,
+		powerSource:     p.powerSource,
+		batterySource:   p.batterySource,
+		sleepSource:     p.sleepSource,
+		sleepInhibitor:  p.sleepInhibitor,
+		udevMonitor:     p.udevMonitor,
+		docks:           p.docks,
+		watchConnectors: p.watchConnectors,
+		sysRoot:         p.sysRoot,
+		configCh:        make(chan Config, 1),
 	}, nil
 }
 
+// dockConfig returns the configured docks. The udev goroutine reads them while the event loop
+// replaces them on config reload, so both go through here to see the same list.
+func (l *listener) dockConfig() []DockDevice {
+	l.docksMu.Lock()
+	defer l.docksMu.Unlock()
+	return l.docks
+}
+
+func (l *listener) setDocks(docks []DockDevice) {
+	l.docksMu.Lock()
+	defer l.docksMu.Unlock()
+	l.docks = docks
+}
+
 // listenAndHandle starts the hyprdocked listener, which handles hyprctl display add/remove events
 // and events from the hyprdocked CLI.
 func (a *App) listenAndHandle(ctx context.Context) error {
@@ -96,7 +163,10 @@ func (a *App) listenAndHandle(ctx context.Context) error {
 				doneChans = append(doneChans, ev.Done)
 			}
 
-			if a.mode == modeIdle && ev.Type != resumeCmdEvent {
+			// Sleep is still handled in idle mode (e.g. after the idle command), since the
+			// sleep inhibitor is only released once an event has been processed. So is a
+			// Hyprland restart: it reloads the monitor config, and only idle rules match then.
+			if a.mode == modeIdle && !handledInIdle(ev.Type) {
 				slog.Debug("received event from listener; in idle mode, skipping processing", "type", ev.Type, "details", ev.Details)
 				for _, done := range doneChans {
 					done <- nil
@@ -112,12 +182,25 @@ func (a *App) listenAndHandle(ctx context.Context) error {
 			case idleCmdEvent:
 				slog.Info("idle command received", "source", ev.Details)
 				a.mode = modeIdle
+			case sleepEvent:
+				slog.Info("system preparing for sleep")
+				a.mode = modeIdle
+				a.sleeping = true
+			case wakeEvent:
+				slog.Info("system woke from sleep")
+				a.mode = modeNormal
+				a.sleeping = false
+				a.acquireSleepInhibitor(ctx)
 			case pingCmdEvent:
 				slog.Info("ping command received")
 				for _, done := range doneChans {
 					done <- nil
 				}
 				continue
+			case batteryChangeEvent:
+				if !a.refreshBattery(ctx) {
+					continue
+				}
 			}
 
 			// Wait briefly to let the system settle and coalesce any concurrently buffered
@@ -126,7 +209,12 @@ func (a *App) listenAndHandle(ctx context.Context) error {
 			if sw <= 0 {
 				sw = defaultSettleWindow
 			}
-			settle := time.NewTimer(time.Duration(sw) * time.Second)
+			settleFor := time.Duration(sw) * time.Second
+			if ev.Type == sleepEvent {
+				// the system sleeps as soon as logind's delay is over; there is no time to wait
+				settleFor = 0
+			}
+			settle := time.NewTimer(settleFor)
 		drain:
 			for {
 				select {
@@ -149,6 +237,13 @@ func (a *App) listenAndHandle(ctx context.Context) error {
 						a.mode = modeNormal
 					case idleCmdEvent:
 						a.mode = modeIdle
+					case sleepEvent:
+						a.mode = modeIdle
+						a.sleeping = true
+					case wakeEvent:
+						a.mode = modeNormal
+						a.sleeping = false
+						a.acquireSleepInhibitor(ctx)
 					}
 				}
 			}
@@ -173,12 +268,19 @@ func (a *App) listenAndHandle(ctx context.Context) error {
 				a.runPostHooks(changed)
 			}
 
+			// Whatever the outcome, never hold up sleep longer than it took to try.
+			if a.sleeping {
+				a.releaseSleepInhibitor()
+			}
+
 			for _, done := range doneChans {
 				done <- runErr
 			}
 
 		case cfg := <-a.listener.configCh:
 			a.Config = cfg
+			a.listener.setDocks(cfg.Docks)
+			a.profilePending = true
 
 		case err := <-errc:
 			return fmt.Errorf("listener failed: %w", err)
@@ -189,17 +291,44 @@ func (a *App) listenAndHandle(ctx context.Context) error {
 	}
 }
 
+func (a *App) acquireSleepInhibitor(ctx context.Context) {
+	inh := a.listener.sleepInhibitor
+	if inh == nil {
+		return
+	}
+
+	if err := inh.Acquire(ctx); err != nil {
+		slog.Error("acquiring sleep inhibitor", "error", err)
+		return
+	}
+	slog.Debug("sleep inhibitor acquired")
+}
+
+func (a *App) releaseSleepInhibitor() {
+	inh := a.listener.sleepInhibitor
+	if inh == nil || !inh.Held() {
+		return
+	}
+
+	if err := inh.Release(); err != nil {
+		slog.Error("releasing sleep inhibitor", "error", err)
+		return
+	}
+	slog.Debug("sleep inhibitor released")
+}
+
 func (a *App) refreshState(ctx context.Context) {
 	if ds, err := a.hctl.ListMonitors(); err == nil {
 		if !reflect.DeepEqual(a.allDisplays, ds) {
 			a.allDisplays = ds
 			slog.Debug("displays state refreshed", "displays", ds)
 		}
+		a.checkMonitorSet()
 	} else {
 		slog.Error("refreshing displays", "error", err)
 	}
 
-	if ls, err := a.listener.lidHandler.GetCurrentState(ctx); err == nil {
+	if ls, err := a.listener.lidSource.GetCurrentState(ctx); err == nil {
 		if a.lidState != ls {
 			a.lidState = ls
 			slog.Debug("lid state refreshed", "state", ls)
@@ -207,6 +336,45 @@ func (a *App) refreshState(ctx context.Context) {
 	} else {
 		slog.Error("refreshing lid state", "error", err)
 	}
+
+	if ps, err := a.listener.powerSource.GetCurrentState(ctx); err == nil {
+		if a.powerState != ps {
+			a.powerState = ps
+			slog.Debug("power state refreshed", "state", ps)
+		}
+	} else {
+		slog.Error("refreshing power state", "error", err)
+	}
+
+	if docks := a.listener.dockConfig(); len(docks) > 0 {
+		if found, err := scanDocks(a.listener.sysRoot, docks); err == nil {
+			if ds := dockNames(found); !slices.Equal(a.docks, ds) {
+				a.docks = ds
+				slog.Debug("docks state refreshed", "docks", ds)
+			}
+		} else {
+			slog.Error("refreshing docks", "error", err)
+		}
+	}
+
+	if a.listener.watchConnectors {
+		if cn, err := crossCheckConnectors(a.listener.sysRoot, a.allDisplays); err == nil {
+			if !slices.Equal(a.connectors, cn) {
+				a.connectors = cn
+				slog.Debug("connectors state refreshed", "connectors", cn)
+			}
+		} else {
+			// a stale list would keep newly plugged displays from counting as docked
+			slog.Error("refreshing connectors", "error", err)
+			a.connectors = nil
+		}
+	}
+
+	if b, err := a.listener.batterySource.GetCurrentState(ctx); err == nil {
+		a.battery = b
+	} else {
+		slog.Debug("refreshing battery state", "error", err)
+	}
 }
 
 func (l *listener) listen(ctx context.Context, events chan<- listenerEvent) error {
@@ -225,6 +393,44 @@ func (l *listener) listen(ctx context.Context, events chan<- listenerEvent) erro
 		}
 	}()
 
+	go func() {
+		slog.Debug("listening for power events")
+		if err := l.listenPowerEvents(ctx, events); err != nil {
+			errc <- fmt.Errorf("power listener: %w", err)
+		}
+	}()
+
+	go func() {
+		slog.Debug("listening for battery events")
+		if err := l.listenBatteryEvents(ctx, events); err != nil {
+			errc <- fmt.Errorf("battery listener: %w", err)
+		}
+	}()
+
+	if l.sleepSource != nil {
+		go func() {
+			slog.Debug("listening for sleep events")
+			if err := l.listenSleepEvents(ctx, events); err != nil {
+				errc <- fmt.Errorf("sleep listener: %w", err)
+			}
+		}()
+	}
+
+	if l.udevMonitor != nil {
+		go func() {
+			slog.Debug("listening for udev events")
+			err := l.listenUdevEvents(ctx, events)
+			switch {
+			case err == nil || ctx.Err() != nil:
+			case len(l.dockConfig()) == 0:
+				// connectors only cross-check Hyprland, so losing them isn't worth stopping for
+				slog.Warn("udev listener stopped; no longer watching connectors", "error", err)
+			default:
+				errc <- fmt.Errorf("udev listener: %w", err)
+			}
+		}()
+	}
+
 	go func() {
 		slog.Debug("listening for command events")
 		if err := l.listenCommandEvents(ctx, events); err != nil {
@@ -240,9 +446,54 @@ func (l *listener) listen(ctx context.Context, events chan<- listenerEvent) erro
 	}
 }
 
+// handledInIdle reports whether events of type t are processed in idle mode.
+func handledInIdle(t eventType) bool {
+	switch t {
+	case resumeCmdEvent, wakeEvent, sleepEvent, hyprReconnectEvent:
+		return true
+	}
+	return false
+}
+
 // listenHyprctl listens for hyprctl events and sends an event if it is a display add or removal.
+// If the event stream ends (e.g. Hyprland restarted), it reconnects and sends a reconnect event so
+// state is fully refreshed.
 func (l *listener) listenHyprctl(ctx context.Context, events chan<- listenerEvent) error {
-	var lastEvent listenerEvent
+	for {
+		if err := l.scanHyprctl(ctx, events); err != nil {
+			slog.Warn("hyprland event stream ended with error", "error", err)
+		} else {
+			slog.Warn("hyprland event stream closed")
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		slog.Info("reconnecting to hyprland event socket")
+		if err := l.hctlSocketConn.Reconnect(ctx); err != nil {
+			return fmt.Errorf("reconnecting: %w", err)
+		}
+		slog.Info("reconnected to hyprland event socket")
+
+		select {
+		case events <- listenerEvent{Type: hyprReconnectEvent}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// scanHyprctl reads the event stream until it ends. Monitors are tracked by ID from v2 events, so
+// an unplug/replug of the same connector is passed through while duplicate adds are dropped.
+// Once any v2 event has been seen, v1 display events are ignored.
+func (l *listener) scanHyprctl(ctx context.Context, events chan<- listenerEvent) error {
+	var (
+		lastEvent listenerEvent
+		connected = make(map[int64]displayEvent)
+		v2        bool // Hyprland sends v2 display events
+	)
+
 	scn := bufio.NewScanner(l.hctlSocketConn)
 	for scn.Scan() {
 		select {
@@ -261,13 +512,45 @@ func (l *listener) listenHyprctl(ctx context.Context, events chan<- listenerEven
 				continue
 			}
 
-			// store and check for last event so it doesn't attempt to send an unnecessary event if received
-			if reflect.DeepEqual(lastEvent, ev) {
-				slog.Debug("hyprctl listener: new event matches last event, no action needed")
+			if ev.Display == nil {
+				if v2 {
+					continue
+				}
+
+				// v1 events carry only a name, so fall back to dropping exact repeats
+				if reflect.DeepEqual(lastEvent, ev) {
+					slog.Debug("hyprctl listener: new event matches last event, no action needed")
+					continue
+				}
+				lastEvent = ev
+				events <- ev
+				continue
+			}
+
+			// Hyprland sends each v1 event right before its v2 counterpart, so the first v2 event
+			// may be for a change that was already passed on.
+			d := *ev.Display
+			passedOn := !v2 && lastEvent.Type == ev.Type && lastEvent.Details == d.Name
+			v2 = true
+
+			switch ev.Type {
+			case displayAddEvent:
+				if _, ok := connected[d.ID]; ok {
+					slog.Debug("hyprctl listener: monitor already tracked, no action needed", "id", d.ID, "name", d.Name)
+					continue
+				}
+				connected[d.ID] = d
+			case displayRemoveEvent:
+				delete(connected, d.ID)
+			}
+
+			if passedOn {
+				slog.Debug("hyprctl listener: v1 event already passed on, no action needed", "id", d.ID, "name", d.Name)
 				continue
 			}
 
-			lastEvent = ev
+			slog.Debug("hyprctl listener: display event",
+				"type", ev.Type, "id", d.ID, "name", d.Name, "desc", d.Description, "connected", len(connected))
 			events <- ev
 		}
 	}
@@ -281,12 +564,12 @@ func (l *listener) listenHyprctl(ctx context.Context, events chan<- listenerEven
 
 func (l *listener) listenLidEvents(ctx context.Context, events chan<- listenerEvent) error {
 	go func() {
-		if err := l.lidHandler.ListenForChanges(ctx); err != nil && err != context.Canceled {
+		if err := l.lidSource.ListenForChanges(ctx); err != nil && err != context.Canceled {
 			slog.Error("lid listener stopped", "error", err)
 		}
 	}()
 
-	for range l.lidHandler.Events {
+	for range l.lidSource.Changes() {
 		select {
 		case events <- listenerEvent{Type: lidSwitchEvent}:
 		case <-ctx.Done():
@@ -297,6 +580,65 @@ func (l *listener) listenLidEvents(ctx context.Context, events chan<- listenerEv
 	return nil
 }
 
+func (l *listener) listenPowerEvents(ctx context.Context, events chan<- listenerEvent) error {
+	go func() {
+		if err := l.powerSource.ListenForChanges(ctx); err != nil && err != context.Canceled {
+			slog.Error("power listener stopped", "error", err)
+		}
+	}()
+
+	for range l.powerSource.Changes() {
+		select {
+		case events <- listenerEvent{Type: powerChangeEvent}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (l *listener) listenBatteryEvents(ctx context.Context, events chan<- listenerEvent) error {
+	go func() {
+		if err := l.batterySource.ListenForChanges(ctx); err != nil && err != context.Canceled {
+			slog.Error("battery listener stopped", "error", err)
+		}
+	}()
+
+	for range l.batterySource.Changes() {
+		select {
+		case events <- listenerEvent{Type: batteryChangeEvent}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (l *listener) listenSleepEvents(ctx context.Context, events chan<- listenerEvent) error {
+	go func() {
+		if err := l.sleepSource.ListenForChanges(ctx); err != nil && err != context.Canceled {
+			slog.Error("sleep listener stopped", "error", err)
+		}
+	}()
+
+	for sleeping := range l.sleepSource.Changes() {
+		ev := listenerEvent{Type: wakeEvent}
+		if sleeping {
+			ev.Type = sleepEvent
+		}
+
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
 func (l *listener) listenCommandEvents(ctx context.Context, events chan<- listenerEvent) error {
 	sock := filepath.Join(os.TempDir(), cmdSockName)
 
@@ -381,7 +723,35 @@ func parseDisplayEvent(line string) (listenerEvent, error) {
 	if et, ok := displayEvents[parts[0]]; ok {
 		ev.Type = et
 		ev.Details = parts[1]
+	} else if et, ok := displayEventsV2[parts[0]]; ok {
+		d, err := parseDisplayEventV2(parts[1])
+		if err != nil {
+			return listenerEvent{}, err
+		}
+		ev.Type = et
+		ev.Details = parts[1]
+		ev.Display = &d
 	}
 
 	return *ev, nil
 }
+
+// parseDisplayEventV2 parses "ID,NAME,DESCRIPTION". The description may itself contain commas.
+func parseDisplayEventV2(data string) (displayEvent, error) {
+	parts := strings.SplitN(data, ",", 3)
+	if len(parts) < 2 {
+		return displayEvent{}, fmt.Errorf("invalid v2 display event data: %q", data)
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return displayEvent{}, fmt.Errorf("invalid monitor id in v2 display event: %q", data)
+	}
+
+	d := displayEvent{ID: id, Name: parts[1]}
+	if len(parts) == 3 {
+		d.Description = parts[2]
+	}
+
+	return d, nil
+}
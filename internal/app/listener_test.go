@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readerSource is an event stream that ends after the given events.
+type readerSource struct {
+	io.Reader
+}
+
+func (readerSource) Close() error                    { return nil }
+func (readerSource) Reconnect(context.Context) error { return nil }
+
+func TestScanHyprctl(t *testing.T) {
+	stream := strings.Join([]string{
+		// plugged in
+		"monitoradded>>DP-1",
+		"monitoraddedv2>>1,DP-1,Dell Inc. DELL U2720Q",
+		"workspace>>1",
+		// unplugged
+		"monitorremoved>>DP-1",
+		"monitorremovedv2>>1,DP-1,Dell Inc. DELL U2720Q",
+		// plugged back in on the same connector
+		"monitoradded>>DP-1",
+		"monitoraddedv2>>2,DP-1,Dell Inc. DELL U2720Q",
+		// announced again without a change
+		"monitoraddedv2>>2,DP-1,Dell Inc. DELL U2720Q",
+		"not an event",
+		"monitoradded>>HDMI-A-1",
+		"monitoraddedv2>>3,HDMI-A-1,Foo, Inc. Projector, Rev 2",
+		"monitoraddedv2>>x,HDMI-A-2,",
+	}, "\n")
+
+	l := &listener{hctlSocketConn: readerSource{strings.NewReader(stream)}}
+	events := make(chan listenerEvent, 16)
+	if err := l.scanHyprctl(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	close(events)
+
+	want := []struct {
+		typ  eventType
+		id   int64 // 0 for a v1 event
+		name string
+		desc string
+	}{
+		{displayAddEvent, 0, "DP-1", ""},
+		{displayRemoveEvent, 1, "DP-1", "Dell Inc. DELL U2720Q"},
+		{displayAddEvent, 2, "DP-1", "Dell Inc. DELL U2720Q"},
+		{displayAddEvent, 3, "HDMI-A-1", "Foo, Inc. Projector, Rev 2"},
+	}
+
+	var got []listenerEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i, w := range want {
+		ev := got[i]
+		if ev.Type != w.typ {
+			t.Errorf("event %d: type = %s, want %s", i, ev.Type, w.typ)
+		}
+
+		if w.id == 0 {
+			if ev.Display != nil || ev.Details != w.name {
+				t.Errorf("event %d: got %+v, want v1 event for %s", i, ev, w.name)
+			}
+			continue
+		}
+
+		if d := ev.Display; d == nil || d.ID != w.id || d.Name != w.name || d.Description != w.desc {
+			t.Errorf("event %d: display = %+v, want %d,%s,%s", i, ev.Display, w.id, w.name, w.desc)
+		}
+	}
+}
+
+func TestParseDisplayEventV2(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    displayEvent
+		wantErr bool
+	}{
+		{"1,DP-1,Dell Inc. DELL U2720Q", displayEvent{ID: 1, Name: "DP-1", Description: "Dell Inc. DELL U2720Q"}, false},
+		{"2,HDMI-A-1,Foo, Inc., Model", displayEvent{ID: 2, Name: "HDMI-A-1", Description: "Foo, Inc., Model"}, false},
+		{"3,eDP-1", displayEvent{ID: 3, Name: "eDP-1"}, false},
+		{"DP-1", displayEvent{}, true},
+		{"one,DP-1,Dell", displayEvent{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			got, err := parseDisplayEventV2(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDisplayEventV2 = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+// MirrorPolicy picks external monitors that mirror the laptop display instead of extending the
+// desktop, e.g. projectors.
+type MirrorPolicy struct {
+	Match      []string `mapstructure:"match"`      // monitor names or description patterns
+	Unprofiled bool     `mapstructure:"unprofiled"` // any external not in the active display profile
+}
+
+func (p MirrorPolicy) enabled() bool {
+	return len(p.Match) > 0 || p.Unprofiled
+}
+
+// mirrorMode is the resolution the laptop display is switched to while it is mirrored, so both
+// sides show the same picture unscaled.
+type mirrorMode struct {
+	width, height int64
+	refresh       float64
+}
+
+// shouldMirror reports whether m should mirror the laptop display.
+func (a *App) shouldMirror(m hypr.Monitor) bool {
+	p := a.Config.Mirror
+	for _, pat := range p.Match {
+		if m.Name == pat || globMatch(pat, m.Description) {
+			return true
+		}
+	}
+
+	if !p.Unprofiled {
+		return false
+	}
+
+	if dp := a.displayProfile; dp != nil {
+		for _, pm := range dp.Monitors {
+			if pm.matches(m) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// applyMirror adds the changes that start mirroring the laptop display onto matching externals,
+// or stop it for externals that no longer should. Mirroring only makes sense with the lid open;
+// with it closed, mirrored externals go back to extending the desktop.
+func (a *App) applyMirror(lg *slog.Logger, tx *hypr.Transaction) {
+	if !a.Config.Mirror.enabled() && len(a.mirroring) == 0 {
+		return
+	}
+
+	if a.mirroring == nil {
+		a.mirroring = make(map[string]hypr.Monitor)
+	}
+
+	laptop := a.laptopDisplay
+	open := a.lidState == power.LidStateOpened
+	present := make(map[string]bool)
+	a.mirrorMode = nil
+	for _, m := range a.externalDisplays() {
+		present[m.Name] = true
+		if m.Disabled && m.Mirror == "" {
+			continue
+		}
+
+		if open && a.Config.Mirror.enabled() && a.shouldMirror(m) {
+			orig, mirrored := a.mirroring[m.Name]
+			if !mirrored {
+				orig = m
+			}
+
+			want := orig
+			want.Mirror = laptop.Name
+			mode, ok := commonMode(laptop, orig)
+			if ok {
+				want.Width, want.Height, want.RefreshRate = mode.width, mode.height, bestRefreshRate(orig, mode.width, mode.height)
+				if a.mirrorMode == nil {
+					a.mirrorMode = &mode
+				}
+			}
+
+			if !mirrored || m.Mirror != laptop.Name || m.Width != want.Width || m.Height != want.Height {
+				lg.Info("[UPDATER]mirroring laptop display", "monitor", m.Name, "width", want.Width, "height", want.Height)
+				tx.EnableOrUpdateMonitor(want)
+			}
+			a.mirroring[m.Name] = orig
+			continue
+		}
+
+		if orig, ok := a.mirroring[m.Name]; ok {
+			lg.Info("[UPDATER]stopping mirror", "monitor", m.Name)
+			orig.Mirror = ""
+			tx.EnableOrUpdateMonitor(orig)
+			delete(a.mirroring, m.Name)
+		}
+	}
+
+	// A mirrored display that was unplugged needs nothing undone on its side; the laptop goes
+	// back to its own mode through desiredLaptop now that mirrorMode is cleared.
+	for name := range a.mirroring {
+		if !present[name] {
+			lg.Info("[UPDATER]mirrored display removed", "monitor", name)
+			delete(a.mirroring, name)
+		}
+	}
+}
+
+// commonMode returns the largest resolution both monitors support, with the laptop's best
+// refresh rate at it.
+func commonMode(laptop, ext hypr.Monitor) (mirrorMode, bool) {
+	extModes := make(map[[2]int64]bool)
+	for _, mode := range ext.AvailableModes {
+		if w, h, _, err := hypr.ParseMode(strings.TrimSpace(mode)); err == nil {
+			extModes[[2]int64{w, h}] = true
+		}
+	}
+
+	var best mirrorMode
+	for _, mode := range laptop.AvailableModes {
+		w, h, _, err := hypr.ParseMode(strings.TrimSpace(mode))
+		if err != nil || !extModes[[2]int64{w, h}] {
+			continue
+		}
+		if w*h > best.width*best.height {
+			best = mirrorMode{width: w, height: h}
+		}
+	}
+
+	if best.width == 0 {
+		return mirrorMode{}, false
+	}
+
+	best.refresh = bestRefreshRate(laptop, best.width, best.height)
+	return best, true
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+func TestCommonMode(t *testing.T) {
+	laptop := hypr.Monitor{
+		Name:           "eDP-1",
+		AvailableModes: []string{"2880x1800@120.00Hz", "1920x1080@60.00Hz", "1920x1080@120.00Hz", "1280x720@60.00Hz"},
+	}
+
+	tests := []struct {
+		name     string
+		extModes []string
+		want     mirrorMode
+		wantOK   bool
+	}{
+		{
+			"largest shared resolution",
+			[]string{"3840x2160@60.00Hz", "1920x1080@60.00Hz", "1280x720@60.00Hz"},
+			mirrorMode{width: 1920, height: 1080, refresh: 120},
+			true,
+		},
+		{
+			"refresh rate from the laptop",
+			[]string{"1280x720@30.00Hz", "1920x1080@24.00Hz"},
+			mirrorMode{width: 1920, height: 1080, refresh: 120},
+			true,
+		},
+		{"no shared resolution", []string{"3840x2160@60.00Hz", "2560x1440@144.00Hz"}, mirrorMode{}, false},
+		{"no modes", nil, mirrorMode{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := commonMode(laptop, hypr.Monitor{Name: "HDMI-A-1", AvailableModes: tt.extModes})
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("mode = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMirror(t *testing.T) {
+	laptop := hypr.Monitor{
+		Name: "eDP-1", Width: 2880, Height: 1800, RefreshRate: 120, Scale: 2,
+		AvailableModes: []string{"2880x1800@120.00Hz", "1920x1080@60.00Hz"},
+	}
+	projector := hypr.Monitor{
+		Name: "HDMI-A-1", Description: "Epson PowerLite", Width: 1280, Height: 800, RefreshRate: 60, Scale: 1, X: 1440,
+		AvailableModes: []string{"1280x800@60.00Hz", "1920x1080@50.00Hz", "1920x1080@60.00Hz"},
+	}
+
+	mirrored := projector
+	mirrored.Mirror = laptop.Name
+	mirrored.Width, mirrored.Height = 1920, 1080
+
+	tests := []struct {
+		name          string
+		policy        MirrorPolicy
+		lid           power.LidState
+		displays      []hypr.Monitor
+		mirroring     map[string]hypr.Monitor
+		want          []string
+		wantMirroring []string
+		wantMode      *mirrorMode
+	}{
+		{
+			name:          "external matches the policy",
+			policy:        MirrorPolicy{Match: []string{"Epson *"}},
+			lid:           power.LidStateOpened,
+			displays:      []hypr.Monitor{laptop, projector},
+			want:          []string{"keyword monitor " + hypr.MonitorToConfigString(mirrored)},
+			wantMirroring: []string{projector.Name},
+			wantMode:      &mirrorMode{width: 1920, height: 1080, refresh: 60},
+		},
+		{
+			name:          "already mirrored",
+			policy:        MirrorPolicy{Match: []string{"HDMI-A-1"}},
+			lid:           power.LidStateOpened,
+			displays:      []hypr.Monitor{laptop, mirrored},
+			mirroring:     map[string]hypr.Monitor{projector.Name: projector},
+			wantMirroring: []string{projector.Name},
+			wantMode:      &mirrorMode{width: 1920, height: 1080, refresh: 60},
+		},
+		{
+			name:      "lid closed",
+			policy:    MirrorPolicy{Match: []string{"HDMI-A-1"}},
+			lid:       power.LidStateClosed,
+			displays:  []hypr.Monitor{laptop, mirrored},
+			mirroring: map[string]hypr.Monitor{projector.Name: projector},
+			want:      []string{"keyword monitor " + hypr.MonitorToConfigString(projector)},
+		},
+		{
+			name:      "mirrored external unplugged",
+			policy:    MirrorPolicy{Match: []string{"HDMI-A-1"}},
+			lid:       power.LidStateOpened,
+			displays:  []hypr.Monitor{laptop},
+			mirroring: map[string]hypr.Monitor{projector.Name: projector},
+		},
+		{
+			name:      "policy turned off",
+			lid:       power.LidStateOpened,
+			displays:  []hypr.Monitor{laptop, mirrored},
+			mirroring: map[string]hypr.Monitor{projector.Name: projector},
+			want:      []string{"keyword monitor " + hypr.MonitorToConfigString(projector)},
+		},
+		{
+			name:     "no policy",
+			lid:      power.LidStateOpened,
+			displays: []hypr.Monitor{laptop, projector},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{
+				Config: Config{Mirror: tt.policy},
+				state: &state{
+					allDisplays:   tt.displays,
+					laptopDisplay: laptop,
+					panels:        []hypr.Monitor{laptop},
+					lidState:      tt.lid,
+					mirroring:     tt.mirroring,
+				},
+			}
+
+			tx := hypr.NewTransaction()
+			a.applyMirror(slog.Default(), tx)
+
+			if got := tx.Commands(); !slices.Equal(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+
+			var names []string
+			for name := range a.mirroring {
+				names = append(names, name)
+			}
+			if !slices.Equal(names, tt.wantMirroring) {
+				t.Errorf("mirroring = %q, want %q", names, tt.wantMirroring)
+			}
+
+			switch {
+			case tt.wantMode == nil && a.mirrorMode != nil:
+				t.Errorf("mirror mode = %+v, want none", *a.mirrorMode)
+			case tt.wantMode != nil && (a.mirrorMode == nil || *a.mirrorMode != *tt.wantMode):
+				t.Errorf("mirror mode = %+v, want %+v", a.mirrorMode, *tt.wantMode)
+			}
+		})
+	}
+}
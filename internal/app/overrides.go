@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+// LaptopOverride replaces some of the laptop display's settings in one status. Empty fields keep
+// the settings captured from Hyprland.
+type LaptopOverride struct {
+	Resolution  string  `mapstructure:"resolution"` // "<width>x<height>"
+	RefreshRate float64 `mapstructure:"refresh-rate"`
+	Scale       float64 `mapstructure:"scale"`
+	Transform   *int    `mapstructure:"transform"`
+}
+
+// apply returns m with the override applied. A new resolution without a refresh rate uses the
+// highest one available at it; a resolution m has no mode for is an error.
+func (o LaptopOverride) apply(m hypr.Monitor) (hypr.Monitor, error) {
+	if o.Resolution != "" {
+		w, h, _, err := hypr.ParseMode(o.Resolution)
+		if err != nil {
+			return m, fmt.Errorf("invalid resolution %q: %w", o.Resolution, err)
+		}
+		if w != m.Width || h != m.Height {
+			r := bestRefreshRate(m, w, h)
+			if r == 0 {
+				return m, fmt.Errorf("resolution %q is not an available mode of %s", o.Resolution, m.Name)
+			}
+			m.Width, m.Height, m.RefreshRate = w, h, r
+		}
+	}
+
+	if o.RefreshRate > 0 {
+		m.RefreshRate = closestRefreshRate(m, o.RefreshRate)
+	}
+
+	if o.Scale > 0 {
+		m.Scale = o.Scale
+	}
+
+	if o.Transform != nil {
+		m.Transform = *o.Transform
+	}
+
+	return m, nil
+}
+
+// laptopOverride returns the override configured for the current status.
+func (a *App) laptopOverride() (LaptopOverride, bool) {
+	o, ok := a.Config.LaptopOverrides[a.statusString()]
+	return o, ok
+}
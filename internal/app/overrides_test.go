@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+func TestLaptopOverrideApply(t *testing.T) {
+	laptop := hypr.Monitor{
+		Name:           "eDP-1",
+		Width:          2880,
+		Height:         1800,
+		RefreshRate:    120,
+		Scale:          2,
+		AvailableModes: []string{"2880x1800@120.00Hz", "2880x1800@60.00Hz", "1920x1200@60.00Hz", "1920x1200@90.00Hz"},
+	}
+	one := 1
+
+	tests := []struct {
+		name    string
+		o       LaptopOverride
+		want    hypr.Monitor
+		wantErr bool
+	}{
+		{"empty", LaptopOverride{}, laptop, false},
+		{
+			"resolution picks best refresh",
+			LaptopOverride{Resolution: "1920x1200"},
+			hypr.Monitor{Width: 1920, Height: 1200, RefreshRate: 90, Scale: 2},
+			false,
+		},
+		{
+			"resolution and refresh",
+			LaptopOverride{Resolution: "1920x1200", RefreshRate: 59},
+			hypr.Monitor{Width: 1920, Height: 1200, RefreshRate: 60, Scale: 2},
+			false,
+		},
+		{
+			"refresh, scale and transform",
+			LaptopOverride{RefreshRate: 60, Scale: 1.5, Transform: &one},
+			hypr.Monitor{Width: 2880, Height: 1800, RefreshRate: 60, Scale: 1.5, Transform: 1},
+			false,
+		},
+		{"unavailable resolution", LaptopOverride{Resolution: "1280x800"}, laptop, true},
+		{"invalid resolution", LaptopOverride{Resolution: "wide"}, laptop, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.o.apply(laptop)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Width != tt.want.Width || got.Height != tt.want.Height || got.RefreshRate != tt.want.RefreshRate ||
+				got.Scale != tt.want.Scale || got.Transform != tt.want.Transform {
+				t.Errorf("apply = %dx%d@%v scale %v transform %d, want %dx%d@%v scale %v transform %d",
+					got.Width, got.Height, got.RefreshRate, got.Scale, got.Transform,
+					tt.want.Width, tt.want.Height, tt.want.RefreshRate, tt.want.Scale, tt.want.Transform)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/drm"
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+// InternalPanel sets how an additional internal panel, such as the second screen of a
+// dual-screen laptop, is handled. Panels are turned on and off with the main laptop display
+// unless Docked says otherwise.
+type InternalPanel struct {
+	Name   string `mapstructure:"name"`
+	Docked string `mapstructure:"docked"` // "off" keeps the panel off while docked
+}
+
+const panelDockedOff = "off"
+
+// panelConfig is the part of the config used to identify internal panels.
+type panelConfig struct {
+	name   string         // main laptop display name
+	match  *regexp.Regexp // matched against monitor names and descriptions; may be nil
+	panels []InternalPanel
+}
+
+// identifyPanels returns every internal panel among displays, the main laptop display first.
+// Panels are found by connector type in <sysRoot>/class/drm, falling back to connector names if
+// DRM reports none. Displays matching the configured name, pattern or panels always count. The
+// main display is the configured one, otherwise the first internal panel by connector type (eDP
+// before LVDS and DSI), then by name.
+func identifyPanels(sysRoot string, cfg panelConfig, displays []hypr.Monitor) ([]hypr.Monitor, error) {
+	cs, err := drm.ScanConnectors(sysRoot)
+	if err != nil {
+		slog.Warn("reading drm connectors; identifying panels by name", "error", err)
+	}
+	fromDRM := drm.InternalNames(cs)
+
+	var panels []hypr.Monitor
+	for _, m := range displays {
+		if isInternal(m, fromDRM, cfg) {
+			panels = append(panels, m)
+		}
+	}
+
+	if len(panels) == 0 {
+		return nil, errors.New("could not identify a laptop display")
+	}
+
+	slices.SortStableFunc(panels, func(x, y hypr.Monitor) int {
+		xm, ym := sameDisplayName(x.Name, cfg.name), sameDisplayName(y.Name, cfg.name)
+		switch {
+		case xm && !ym:
+			return -1
+		case ym && !xm:
+			return 1
+		}
+		if c := cmp.Compare(internalTypeRank(x.Name), internalTypeRank(y.Name)); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Name, y.Name)
+	})
+
+	for i := range panels {
+		// A panel may have been parked off-screen by a previous run; don't restore it there.
+		if atOffset(panels[i]) {
+			panels[i].X, panels[i].Y = 0, 0
+		}
+	}
+
+	return panels, nil
+}
+
+// isInternal reports whether m is a built-in panel, by its DRM connector type if known, otherwise
+// by name, or because the config says so.
+func isInternal(m hypr.Monitor, fromDRM []string, cfg panelConfig) bool {
+	if slices.Contains(fromDRM, m.Name) {
+		return true
+	}
+
+	if len(fromDRM) == 0 && internalTypeRank(m.Name) < len(drm.InternalTypes) {
+		return true
+	}
+
+	if sameDisplayName(m.Name, cfg.name) {
+		return true
+	}
+
+	if cfg.match != nil && (cfg.match.MatchString(m.Name) || cfg.match.MatchString(m.Description)) {
+		return true
+	}
+
+	return slices.ContainsFunc(cfg.panels, func(p InternalPanel) bool { return p.Name == m.Name })
+}
+
+// internalTypeRank returns the index in drm.InternalTypes of the panel type name starts with,
+// ignoring case and dashes, or len(drm.InternalTypes) if it isn't an internal panel name.
+func internalTypeRank(name string) int {
+	trimmed := trimmedDisplayName(name)
+	for i, t := range drm.InternalTypes {
+		if strings.HasPrefix(trimmed, strings.ToLower(t)) {
+			return i
+		}
+	}
+
+	return len(drm.InternalTypes)
+}
+
+// sameDisplayName compares display names ignoring case and dashes, so "eDP1" matches "eDP-1".
+func sameDisplayName(name, cfgName string) bool {
+	return cfgName != "" && trimmedDisplayName(name) == trimmedDisplayName(cfgName)
+}
+
+// isPanel reports whether name is one of the laptop's internal panels.
+func (s *state) isPanel(name string) bool {
+	return slices.ContainsFunc(s.panels, func(p hypr.Monitor) bool { return p.Name == name })
+}
+
+// currentDisplay returns the display named name as last reported by Hyprland.
+func (s *state) currentDisplay(name string) (hypr.Monitor, bool) {
+	for _, m := range s.allDisplays {
+		if m.Name == name {
+			return m, true
+		}
+	}
+
+	return hypr.Monitor{}, false
+}
+
+// inUse reports whether m is enabled in Hyprland and not parked at the off-screen position, nor
+// turned off by hyprdocked with DPMS.
+func (a *App) inUse(m hypr.Monitor) bool {
+	return !m.Disabled && !atOffset(m) && !(a.managesDPMS(m.Name) && !m.DPMSStatus)
+}
+
+// managesDPMS reports whether the DPMS state of the display named name is hyprdocked's to
+// manage: with the dpms closed strategy, or once hyprdocked has turned it off itself.
+func (a *App) managesDPMS(name string) bool {
+	return a.Config.closedStrategy() == closedStrategyDPMS || a.dpmsOff[name]
+}
+
+// panelWanted reports whether panel p should be on whenever the laptop display is.
+func (a *App) panelWanted(p hypr.Monitor) bool {
+	if p.Name == a.laptopDisplay.Name {
+		return true
+	}
+
+	for _, c := range a.Config.Panels {
+		if c.Name == p.Name && c.Docked == panelDockedOff {
+			switch a.status() {
+			case statusDockedOpened, statusDockedClosed:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// panelsReady reports whether every panel is in use exactly when it is wanted.
+func (a *App) panelsReady() bool {
+	for _, p := range a.panels {
+		cur, ok := a.currentDisplay(p.Name)
+		if !ok {
+			continue
+		}
+		if a.inUse(cur) != a.panelWanted(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyPanelInUse reports whether any internal panel is in use.
+func (a *App) anyPanelInUse() bool {
+	for _, p := range a.panels {
+		if cur, ok := a.currentDisplay(p.Name); ok && a.inUse(cur) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// desiredPanel returns the settings to apply to panel p along with tx. The main laptop display
+// gets the full set of overrides; other panels keep the settings captured at startup.
+func (a *App) desiredPanel(p hypr.Monitor, tx *hypr.Transaction) hypr.Monitor {
+	if p.Name == a.laptopDisplay.Name {
+		return a.desiredLaptop(tx)
+	}
+
+	return p
+}
+
+func logPanels(panels []hypr.Monitor) {
+	for _, p := range panels {
+		slog.Info("identified laptop display", "name", p.Name, "desc", p.Description, "disabled", p.Disabled)
+	}
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+func TestIdentifyPanels(t *testing.T) {
+	displays := []hypr.Monitor{
+		{Name: "HDMI-A-1", Description: "Dell Inc. DELL U2720Q"},
+		{Name: "eDP-1", Description: "BOE 0x0BCA"},
+		{Name: "DSI-1", Description: "Lenovo Secondary Panel"},
+		{Name: "LVDS-1", Description: "AU Optronics 0x213D"},
+	}
+
+	tests := []struct {
+		name       string
+		connectors []string // class/drm directories to create
+		displays   []hypr.Monitor
+		cfg        panelConfig
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "drm connector types",
+			connectors: []string{"card1-eDP-1", "card1-LVDS-1", "card1-DSI-1", "card1-HDMI-A-1"},
+			displays:   displays,
+			want:       []string{"eDP-1", "LVDS-1", "DSI-1"},
+		},
+		{
+			name:       "drm only lists some panels",
+			connectors: []string{"card1-eDP-1", "card1-HDMI-A-1"},
+			displays:   displays,
+			want:       []string{"eDP-1"},
+		},
+		{
+			name:     "name prefixes without drm",
+			displays: displays,
+			want:     []string{"eDP-1", "LVDS-1", "DSI-1"},
+		},
+		{
+			name:       "name prefixes with only external connectors",
+			connectors: []string{"card0-HDMI-A-1", "card0-DP-1"},
+			displays:   displays,
+			want:       []string{"eDP-1", "LVDS-1", "DSI-1"},
+		},
+		{
+			name:       "configured name first",
+			connectors: []string{"card1-eDP-1", "card1-LVDS-1", "card1-DSI-1", "card1-HDMI-A-1"},
+			displays:   displays,
+			cfg:        panelConfig{name: "edp1"},
+			want:       []string{"eDP-1", "LVDS-1", "DSI-1"},
+		},
+		{
+			name:       "configured name over connector type",
+			connectors: []string{"card1-eDP-1", "card1-LVDS-1", "card1-DSI-1", "card1-HDMI-A-1"},
+			displays:   displays,
+			cfg:        panelConfig{name: "DSI-1"},
+			want:       []string{"DSI-1", "eDP-1", "LVDS-1"},
+		},
+		{
+			name:       "same type by name",
+			connectors: []string{"card1-eDP-2", "card1-DSI-1", "card1-eDP-1"},
+			displays:   []hypr.Monitor{{Name: "eDP-2"}, {Name: "DSI-1"}, {Name: "eDP-1"}},
+			want:       []string{"eDP-1", "eDP-2", "DSI-1"},
+		},
+		{
+			name:       "laptop-match on description",
+			connectors: []string{"card1-eDP-1", "card1-HDMI-A-1"},
+			displays:   displays,
+			cfg:        panelConfig{match: regexp.MustCompile(`^Lenovo Secondary`)},
+			want:       []string{"eDP-1", "DSI-1"},
+		},
+		{
+			name:       "laptop-match picks an unusual connector",
+			connectors: []string{"card0-HDMI-A-1"},
+			displays:   []hypr.Monitor{{Name: "HDMI-A-1", Description: "Framework Internal"}},
+			cfg:        panelConfig{match: regexp.MustCompile(`Internal`)},
+			want:       []string{"HDMI-A-1"},
+		},
+		{
+			name:       "configured panels",
+			connectors: []string{"card1-eDP-1", "card1-HDMI-A-1"},
+			displays:   displays,
+			cfg:        panelConfig{panels: []InternalPanel{{Name: "DSI-1"}}},
+			want:       []string{"eDP-1", "DSI-1"},
+		},
+		{
+			name:       "no internal panel",
+			connectors: []string{"card0-HDMI-A-1"},
+			displays:   []hypr.Monitor{{Name: "HDMI-A-1"}},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, c := range tt.connectors {
+				writeConnector(t, root, c, "connected")
+			}
+
+			got, err := identifyPanels(root, tt.cfg, tt.displays)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var names []string
+			for _, m := range got {
+				names = append(names, m.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("panels = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentifyPanelsResetsOffset(t *testing.T) {
+	root := t.TempDir()
+	writeConnector(t, root, "card1-eDP-1", "connected")
+
+	displays := []hypr.Monitor{{Name: "eDP-1", X: offsetPosition, Y: offsetPosition}}
+	got, err := identifyPanels(root, panelConfig{}, displays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].X != 0 || got[0].Y != 0 {
+		t.Errorf("position = %dx%d, want 0x0", got[0].X, got[0].Y)
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"cmp"
+	"log/slog"
+	"math"
+	"slices"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+// LaptopPlacement places the laptop display next to the primary external monitor instead of at
+// the position captured at startup.
+type LaptopPlacement struct {
+	Side    string `mapstructure:"side"`    // left, right, above or below the primary external
+	Align   string `mapstructure:"align"`   // start, center or end along that side
+	Primary string `mapstructure:"primary"` // primary external name or description pattern; defaults to the top-left one
+}
+
+const (
+	placeLeft  = "left"
+	placeRight = "right"
+	placeAbove = "above"
+	placeBelow = "below"
+
+	alignStart  = "start"
+	alignCenter = "center"
+	alignEnd    = "end"
+)
+
+func (p LaptopPlacement) enabled() bool {
+	return p.Side != ""
+}
+
+// logicalSize returns the size m takes up in the layout: its mode divided by its scale, with
+// width and height swapped if it is rotated a quarter turn.
+func logicalSize(m hypr.Monitor) (int64, int64) {
+	scale := m.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	w := int64(math.Round(float64(m.Width) / scale))
+	h := int64(math.Round(float64(m.Height) / scale))
+	// transforms 1, 3, 5 and 7 are rotated by 90 or 270 degrees
+	if m.Transform%2 == 1 {
+		w, h = h, w
+	}
+
+	return w, h
+}
+
+// primaryExternal returns the enabled external monitor the laptop display is placed against.
+// Externals already changed in tx (e.g. by the display profile) are taken as tx leaves them.
+func (a *App) primaryExternal(tx *hypr.Transaction) (hypr.Monitor, bool) {
+	var externals []hypr.Monitor
+	for _, m := range a.allDisplays {
+		if q, ok := tx.QueuedMonitor(m.Name); ok {
+			m = q
+		}
+		if !m.Disabled && !a.isPanel(m.Name) && m.Mirror == "" {
+			externals = append(externals, m)
+		}
+	}
+
+	if len(externals) == 0 {
+		return hypr.Monitor{}, false
+	}
+
+	if p := a.Config.LaptopPlacement.Primary; p != "" {
+		for _, m := range externals {
+			if m.Name == p || globMatch(p, m.Description) {
+				return m, true
+			}
+		}
+	}
+
+	// Focus moves around, so fall back to something stable: the top-left external.
+	return slices.MinFunc(externals, func(x, y hypr.Monitor) int {
+		return cmp.Or(cmp.Compare(x.X, y.X), cmp.Compare(x.Y, y.Y))
+	}), true
+}
+
+// placeLaptop moves laptop next to the primary external monitor per the placement config, as it
+// will be once tx is applied. It reports false, leaving laptop as is, if placement is off or there
+// is no external to place against.
+func (a *App) placeLaptop(laptop hypr.Monitor, tx *hypr.Transaction) (hypr.Monitor, bool) {
+	p := a.Config.LaptopPlacement
+	if !p.enabled() {
+		return laptop, false
+	}
+
+	primary, ok := a.primaryExternal(tx)
+	if !ok {
+		return laptop, false
+	}
+
+	pw, ph := logicalSize(primary)
+	lw, lh := logicalSize(laptop)
+
+	switch p.Side {
+	case placeLeft:
+		laptop.X = primary.X - lw
+		laptop.Y = align(p.Align, primary.Y, ph, lh)
+	case placeRight:
+		laptop.X = primary.X + pw
+		laptop.Y = align(p.Align, primary.Y, ph, lh)
+	case placeAbove:
+		laptop.X = align(p.Align, primary.X, pw, lw)
+		laptop.Y = primary.Y - lh
+	case placeBelow:
+		laptop.X = align(p.Align, primary.X, pw, lw)
+		laptop.Y = primary.Y + ph
+	default:
+		slog.Warn("unknown laptop placement side; not placing", "side", p.Side)
+		return laptop, false
+	}
+
+	return laptop, true
+}
+
+// align returns the offset of a span of size inner placed along a span of size outer starting at
+// start.
+func align(mode string, start, outer, inner int64) int64 {
+	switch mode {
+	case alignCenter:
+		return start + (outer-inner)/2
+	case alignEnd:
+		return start + outer - inner
+	case alignStart, "":
+		return start
+	default:
+		slog.Warn("unknown laptop placement alignment; using start", "align", mode)
+		return start
+	}
+}
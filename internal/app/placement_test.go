@@ -0,0 +1,61 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+func TestPlaceLaptop(t *testing.T) {
+	laptop := hypr.Monitor{Name: "eDP-1", Width: 1920, Height: 1200, Scale: 1.25}
+	ext1 := hypr.Monitor{Name: "DP-1", Description: "Dell U2720Q", Width: 2560, Height: 1440, Scale: 1}
+	ext2 := hypr.Monitor{Name: "DP-2", Description: "LG 27GL850", Width: 1920, Height: 1080, Scale: 1, X: 2560}
+
+	// the profile swaps the two externals around in the pending transaction
+	profiled := hypr.NewTransaction()
+	moved1, moved2 := ext1, ext2
+	moved1.X, moved2.X = 1920, 0
+	profiled.EnableOrUpdateMonitor(moved1).EnableOrUpdateMonitor(moved2)
+
+	disabled := hypr.NewTransaction()
+	disabled.DisableMonitor(ext1)
+
+	tests := []struct {
+		name      string
+		placement LaptopPlacement
+		tx        *hypr.Transaction
+		wantX     int64
+		wantY     int64
+		wantOK    bool
+	}{
+		{"off", LaptopPlacement{}, nil, 0, 0, false},
+		{"left of top-left", LaptopPlacement{Side: placeLeft}, nil, -1536, 0, true},
+		{"below centered", LaptopPlacement{Side: placeBelow, Align: alignCenter}, nil, 512, 1440, true},
+		{"right of primary", LaptopPlacement{Side: placeRight, Align: alignEnd, Primary: "LG *"}, nil, 4480, 120, true},
+		{"profile positions", LaptopPlacement{Side: placeLeft}, profiled, -1536, 0, true},
+		{"profile positions primary", LaptopPlacement{Side: placeRight, Primary: "DP-1"}, profiled, 4480, 0, true},
+		{"primary disabled in tx", LaptopPlacement{Side: placeLeft, Primary: "DP-1"}, disabled, 1024, 0, true},
+		{"bad side", LaptopPlacement{Side: "behind"}, nil, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{
+				Config: Config{LaptopPlacement: tt.placement},
+				state: &state{
+					allDisplays:   []hypr.Monitor{laptop, ext1, ext2},
+					laptopDisplay: laptop,
+					panels:        []hypr.Monitor{laptop},
+				},
+			}
+
+			got, ok := a.placeLaptop(laptop, tt.tx)
+			if ok != tt.wantOK {
+				t.Fatalf("placed = %v, want %v", ok, tt.wantOK)
+			}
+			if got.X != tt.wantX || got.Y != tt.wantY {
+				t.Errorf("position = %dx%d, want %dx%d", got.X, got.Y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
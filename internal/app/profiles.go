@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+// PowerProfile maps a status and power state to a power-profiles-daemon profile. An empty
+// Status or Power matches any.
+type PowerProfile struct {
+	Status  string `mapstructure:"status"`
+	Power   string `mapstructure:"power"`
+	Profile string `mapstructure:"profile"`
+}
+
+// profileTracker remembers what hyprdocked last set, so a profile changed by anyone else can be
+// recognized as the user's manual choice.
+type profileTracker struct {
+	set       string            // profile hyprdocked last set or left in place
+	key       string            // status/power key it was set for
+	held      bool              // whether another application held a profile at the time
+	power     power.State       // power source overrides were recorded on
+	user      string            // the user's own choice, restored when no mapping applies
+	overrides map[string]string // manual choices, by status/power key
+}
+
+// profileFor returns the configured profile for the status and power state; the first match wins.
+func profileFor(profiles []PowerProfile, s status, ps power.State) string {
+	for _, p := range profiles {
+		if p.Status != "" && p.Status != s.string() {
+			continue
+		}
+		if p.Power != "" && power.State(p.Power) != ps {
+			continue
+		}
+		return p.Profile
+	}
+
+	return ""
+}
+
+// applyPowerProfile switches to the profile mapped to the current status and power state. If the
+// active profile was changed since hyprdocked last set it, and not by a profile hold, that choice
+// is kept for the status and power state it was made in until the power source changes.
+func (a *App) applyPowerProfile(lg *slog.Logger) {
+	if a.profiles == nil || len(a.Config.PowerProfiles) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	active, err := a.profiles.ActiveProfile(ctx)
+	if err != nil {
+		lg.Error("[UPDATER]could not read power profile", "error", err)
+		return
+	}
+
+	t := &a.powerProfile
+	if t.overrides == nil {
+		t.overrides = make(map[string]string)
+	}
+
+	// Manual choices are made for the power source at hand, so they don't outlive it.
+	if t.power != a.powerState {
+		if len(t.overrides) > 0 {
+			lg.Debug("[UPDATER]power source changed; dropping power profile overrides", "overrides", t.overrides)
+			clear(t.overrides)
+		}
+		t.power = a.powerState
+	}
+
+	// A hold (e.g. power-saver on low battery) is the daemon acting for another application, and
+	// so is it switching back once the hold is released. Neither is the user's choice, and
+	// setting a profile over a hold would cancel it.
+	holds, err := a.profiles.Holds(ctx)
+	if err != nil {
+		lg.Debug("[UPDATER]could not read power profile holds", "error", err)
+	}
+	held, wasHeld := len(holds) > 0, t.held
+	t.held = held
+
+	switch {
+	case t.set == "":
+		t.user = active
+	case active == t.set:
+	case held || wasHeld:
+		lg.Info("[UPDATER]power profile changed by a hold; not treating it as an override", "profile", active, "holds", holds)
+	default:
+		lg.Info("[UPDATER]power profile changed manually; keeping it", "profile", active, "for", t.key)
+		t.overrides[t.key] = active
+		t.user = active
+	}
+
+	key := a.statusString() + "/" + string(a.powerState)
+	if held {
+		t.key, t.set = key, active
+		return
+	}
+
+	want, ok := t.overrides[key]
+	if !ok {
+		want = profileFor(a.Config.PowerProfiles, a.status(), a.powerState)
+	}
+	if want == "" {
+		want = t.user
+	}
+
+	t.key = key
+	if want == "" || want == active {
+		t.set = active
+		return
+	}
+
+	lg.Info("[UPDATER]switching power profile", "from", active, "to", want)
+	if err := a.profiles.SetActiveProfile(ctx, want); err != nil {
+		lg.Error("[UPDATER]could not switch power profile", "profile", want, "error", err)
+		t.set = active
+		return
+	}
+	t.set = want
+}
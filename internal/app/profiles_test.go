@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+// fakeProfiles is an in-memory power-profiles-daemon.
+type fakeProfiles struct {
+	active string
+	holds  []power.ProfileHold
+	err    error
+	sets   []string
+}
+
+func (f *fakeProfiles) ActiveProfile(context.Context) (string, error) {
+	return f.active, f.err
+}
+
+func (f *fakeProfiles) Holds(context.Context) ([]power.ProfileHold, error) {
+	return f.holds, f.err
+}
+
+func (f *fakeProfiles) SetActiveProfile(_ context.Context, profile string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.active = profile
+	f.sets = append(f.sets, profile)
+	return nil
+}
+
+func TestProfileFor(t *testing.T) {
+	profiles := []PowerProfile{
+		{Status: "docked_lid_opened", Power: "ac", Profile: "performance"},
+		{Power: "battery", Profile: "power-saver"},
+		{Status: "docked_lid_opened", Profile: "balanced"},
+	}
+
+	tests := []struct {
+		name   string
+		status status
+		power  power.State
+		want   string
+	}{
+		{"status and power", statusDockedOpened, power.StateOnAC, "performance"},
+		{"first match wins", statusDockedOpened, power.StateOnBattery, "power-saver"},
+		{"power only", statusOnlyLaptopOpened, power.StateOnBattery, "power-saver"},
+		{"no match", statusOnlyLaptopOpened, power.StateOnAC, ""},
+		{"unknown power", statusDockedOpened, power.StateUnknown, "balanced"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profileFor(profiles, tt.status, tt.power); got != tt.want {
+				t.Errorf("profileFor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPowerProfile(t *testing.T) {
+	laptop := hypr.Monitor{Name: "eDP-1"}
+	external := hypr.Monitor{Name: "DP-1"}
+	hold := []power.ProfileHold{{Profile: "power-saver", ApplicationID: "org.gnome.SettingsDaemon.Power"}}
+	profiles := []PowerProfile{
+		{Status: "docked_lid_opened", Profile: "performance"},
+		{Status: "only_laptop_lid_opened", Power: "ac", Profile: "balanced"},
+	}
+
+	// step is one update: the state going into it, a profile someone else switched to before
+	// it, and every profile hyprdocked has set by the end of it.
+	type step struct {
+		docked bool
+		power  power.State
+		active string
+		holds  []power.ProfileHold
+		want   []string
+	}
+
+	tests := []struct {
+		name     string
+		profiles []PowerProfile
+		initial  string
+		steps    []step
+	}{
+		{
+			name:     "manual override kept until the status changes",
+			profiles: profiles,
+			initial:  "balanced",
+			steps: []step{
+				{power: power.StateOnAC},
+				{docked: true, power: power.StateOnAC, want: []string{"performance"}},
+				{docked: true, power: power.StateOnAC, active: "power-saver", want: []string{"performance"}},
+				{docked: true, power: power.StateOnAC, want: []string{"performance"}},
+				{power: power.StateOnAC, want: []string{"performance", "balanced"}},
+				// back in the status it was made in, the override applies again
+				{docked: true, power: power.StateOnAC, want: []string{"performance", "balanced", "power-saver"}},
+				// until the power source changes
+				{docked: true, power: power.StateOnBattery, want: []string{"performance", "balanced", "power-saver", "performance"}},
+			},
+		},
+		{
+			name:     "active hold",
+			profiles: profiles,
+			initial:  "balanced",
+			steps: []step{
+				{docked: true, power: power.StateOnAC, want: []string{"performance"}},
+				{docked: true, power: power.StateOnBattery, active: "power-saver", holds: hold, want: []string{"performance"}},
+				// the hold keeps its profile over the mapping for the new status
+				{power: power.StateOnBattery, holds: hold, want: []string{"performance"}},
+				// the daemon switching back on release isn't an override either
+				{docked: true, power: power.StateOnAC, active: "performance", want: []string{"performance"}},
+				{power: power.StateOnAC, want: []string{"performance", "balanced"}},
+			},
+		},
+		{
+			name:     "no matching profile",
+			profiles: profiles[:1],
+			initial:  "balanced",
+			steps: []step{
+				{power: power.StateOnAC},
+				{docked: true, power: power.StateOnAC, want: []string{"performance"}},
+				// with no mapping, the profile the user had is restored
+				{power: power.StateOnAC, want: []string{"performance", "balanced"}},
+			},
+		},
+		{
+			name:     "no profiles configured",
+			profiles: nil,
+			initial:  "balanced",
+			steps: []step{
+				{docked: true, power: power.StateOnAC},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := &fakeProfiles{active: tt.initial}
+			a := &App{
+				Config:   Config{PowerProfiles: tt.profiles},
+				profiles: pc,
+				state:    &state{laptopDisplay: laptop, panels: []hypr.Monitor{laptop}, lidState: power.LidStateOpened},
+			}
+
+			for i, s := range tt.steps {
+				a.allDisplays = []hypr.Monitor{laptop}
+				if s.docked {
+					a.allDisplays = append(a.allDisplays, external)
+				}
+				a.powerState = s.power
+				if s.active != "" {
+					pc.active = s.active
+				}
+				pc.holds = s.holds
+
+				a.applyPowerProfile(slog.Default())
+				if !slices.Equal(pc.sets, s.want) {
+					t.Fatalf("step %d: set = %q, want %q", i, pc.sets, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPowerProfileUnavailable(t *testing.T) {
+	profiles := []PowerProfile{{Profile: "performance"}}
+
+	// without a profile controller, nothing is read or set
+	a := &App{Config: Config{PowerProfiles: profiles}, state: &state{}}
+	a.applyPowerProfile(slog.Default())
+
+	// the daemon stopped after startup
+	pc := &fakeProfiles{active: "balanced", err: errors.New("org.freedesktop.DBus.Error.ServiceUnknown")}
+	a.profiles = pc
+	a.applyPowerProfile(slog.Default())
+	if len(pc.sets) != 0 {
+		t.Errorf("set = %q, want none", pc.sets)
+	}
+	if a.powerProfile.set != "" {
+		t.Errorf("tracked profile = %q, want none", a.powerProfile.set)
+	}
+
+	// once it is back, the mapping applies
+	pc.err = nil
+	a.applyPowerProfile(slog.Default())
+	if !slices.Equal(pc.sets, []string{"performance"}) {
+		t.Errorf("set = %q, want %q", pc.sets, []string{"performance"})
+	}
+}
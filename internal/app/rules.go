@@ -0,0 +1,430 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/power"
+)
+
+// Rule runs its actions when the current state matches it. Empty fields match anything, except
+// Idle: rules only match in idle mode if they ask for it, so the laptop display can't be left off
+// going into sleep by a rule written for normal use.
+type Rule struct {
+	Name     string       `mapstructure:"name"`
+	Lid      string       `mapstructure:"lid"`      // "opened" or "closed"
+	Docked   *bool        `mapstructure:"docked"`   // docked by an external display or dock device
+	Power    string       `mapstructure:"power"`    // "ac" or "battery"
+	Idle     bool         `mapstructure:"idle"`     // idle mode, entered before sleep
+	Monitors []string     `mapstructure:"monitors"` // description or name patterns that must all be connected
+	Actions  []RuleAction `mapstructure:"actions"`
+}
+
+// RuleAction is one step of a rule. Which fields are used depends on Action.
+type RuleAction struct {
+	Action   string `mapstructure:"action"`
+	Monitor  string `mapstructure:"monitor"`  // monitor name or description pattern
+	Position string `mapstructure:"position"` // "<x>x<y>", for enable and position
+	State    string `mapstructure:"state"`    // "on" or "off", for dpms
+	Sleep    string `mapstructure:"sleep"`    // sleep action, for suspend
+	Command  string `mapstructure:"command"`  // shell command, for run
+}
+
+const (
+	actionEnable   = "enable"
+	actionDisable  = "disable"
+	actionDPMS     = "dpms"
+	actionPosition = "position"
+	actionSuspend  = "suspend"
+	actionLock     = "lock"
+	actionRun      = "run"
+
+	// Built-in behavior, used by the default rules.
+	actionLaptopOn      = "laptop-on"      // enable the laptop display, or update its settings
+	actionLaptopOff     = "laptop-off"     // take the laptop display out of use per closed-strategy
+	actionLowBattery    = "low-battery"    // battery closed-action if the battery is low; stops the rule if it acts
+	actionSuspendClosed = "suspend-closed" // sleep per suspend-closed settings
+	actionSuspendIdle   = "suspend-idle"   // sleep per suspend-idle settings
+)
+
+// defaultRules are the built-in behavior. They are checked after any configured rules.
+var defaultRules = []Rule{
+	{
+		Name:    "idle",
+		Idle:    true,
+		Actions: []RuleAction{{Action: actionLaptopOn}, {Action: actionSuspendIdle}},
+	},
+	{
+		Name:    "lid-opened",
+		Lid:     string(power.LidStateOpened),
+		Actions: []RuleAction{{Action: actionLaptopOn}},
+	},
+	{
+		Name:    "laptop-closed",
+		Lid:     string(power.LidStateClosed),
+		Docked:  new(false),
+		Actions: []RuleAction{{Action: actionLaptopOn}, {Action: actionLowBattery}, {Action: actionSuspendClosed}},
+	},
+	{
+		Name:    "docked-closed",
+		Lid:     string(power.LidStateClosed),
+		Docked:  new(true),
+		Actions: []RuleAction{{Action: actionLaptopOff}, {Action: actionLowBattery}},
+	},
+}
+
+// validateRules reports the first rule with a lid, power or action value that would never match
+// or run.
+func validateRules(rules []Rule) error {
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+
+		switch power.LidState(r.Lid) {
+		case "", power.LidStateOpened, power.LidStateClosed:
+		default:
+			return fmt.Errorf("rule %s: unknown lid %q (want opened or closed)", name, r.Lid)
+		}
+
+		switch power.State(r.Power) {
+		case "", power.StateOnAC, power.StateOnBattery:
+		default:
+			return fmt.Errorf("rule %s: unknown power %q (want ac or battery)", name, r.Power)
+		}
+
+		for _, act := range r.Actions {
+			if err := act.validate(); err != nil {
+				return fmt.Errorf("rule %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (act RuleAction) validate() error {
+	switch act.Action {
+	case actionEnable, actionDisable, actionPosition:
+	case actionDPMS:
+		switch act.State {
+		case "", "on", "off":
+		default:
+			return fmt.Errorf("unknown dpms state %q (want on or off)", act.State)
+		}
+	case actionSuspend:
+		if act.Sleep != "" && !power.SleepAction(act.Sleep).Valid() {
+			return fmt.Errorf("unknown sleep action %q", act.Sleep)
+		}
+	case actionLock, actionRun:
+	case actionLaptopOn, actionLaptopOff, actionLowBattery, actionSuspendClosed, actionSuspendIdle:
+	default:
+		return fmt.Errorf("unknown action %q", act.Action)
+	}
+
+	return nil
+}
+
+// matchRule returns the first configured or default rule matching the current state.
+func (a *App) matchRule() (Rule, bool) {
+	for _, rs := range [][]Rule{a.Config.Rules, defaultRules} {
+		for _, r := range rs {
+			if a.ruleMatches(r) {
+				return r, true
+			}
+		}
+	}
+
+	return Rule{}, false
+}
+
+func (a *App) ruleMatches(r Rule) bool {
+	if r.Idle != (a.mode == modeIdle) {
+		return false
+	}
+
+	if r.Lid != "" && power.LidState(r.Lid) != a.lidState {
+		return false
+	}
+
+	if r.Docked != nil {
+		switch a.status() {
+		case statusDockedOpened, statusDockedClosed:
+			if !*r.Docked {
+				return false
+			}
+		case statusOnlyLaptopOpened, statusOnlyLaptopClosed:
+			if *r.Docked {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	if r.Power != "" && power.State(r.Power) != a.powerState {
+		return false
+	}
+
+	for _, p := range r.Monitors {
+		if len(a.matchMonitors(p)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchMonitors returns the known monitors whose name or description matches pattern.
+func (a *App) matchMonitors(pattern string) []hypr.Monitor {
+	var ms []hypr.Monitor
+	for _, m := range a.allDisplays {
+		if m.Name == pattern || globMatch(pattern, m.Description) {
+			ms = append(ms, m)
+		}
+	}
+
+	return ms
+}
+
+func globMatch(pattern, s string) bool {
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
+}
+
+// ruleRun carries a rule's display changes between actions. Display actions are collected in one
+// transaction, which is applied before any action that acts outside of Hyprland and at the end.
+type ruleRun struct {
+	a       *App
+	lg      *slog.Logger
+	tx      *hypr.Transaction
+	changed bool
+	err     error
+
+	// restoreWorkspaces is set when the laptop display is being enabled while docked, so the
+	// workspaces moved off it are brought back once Hyprland reports it up.
+	restoreWorkspaces bool
+}
+
+// runRule runs r's actions in order, and reports whether any display changes were made.
+func (a *App) runRule(lg *slog.Logger, r Rule) (bool, error) {
+	run := &ruleRun{a: a, lg: lg, tx: hypr.NewTransaction()}
+	var profiled bool
+	if a.mode != modeIdle {
+		a.restorePendingWorkspaces(lg)
+		profiled = a.applyDisplayProfile(lg, run.tx)
+		a.applyMirror(lg, run.tx)
+	}
+	for _, act := range r.Actions {
+		if stop := run.do(act); stop {
+			break
+		}
+	}
+	run.flush()
+
+	if profiled && run.err != nil {
+		lg.Debug("[UPDATER]display profile not applied; retrying on the next update")
+		a.profilePending = true
+	}
+
+	return run.changed, run.err
+}
+
+// flush applies the pending display changes.
+func (r *ruleRun) flush() {
+	if r.tx.Len() == 0 {
+		return
+	}
+
+	r.changed = true
+	restore := r.restoreWorkspaces
+	r.restoreWorkspaces = false
+	err := r.a.applyTx(r.lg, r.tx)
+	r.tx = hypr.NewTransaction()
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	// A monitor enabled by keyword is brought up asynchronously, so dispatches targeting it
+	// fail until Hyprland reports it. The workspaces are moved on the update that follows its
+	// monitor added event.
+	if restore && r.a.Config.MigrateWorkspaces && len(r.a.laptopWorkspaces) > 0 {
+		r.lg.Debug("[UPDATER]restoring laptop workspaces once the laptop display is up")
+		r.a.workspacesPending = true
+	}
+}
+
+// do runs a single action, and reports whether the rule should stop.
+func (r *ruleRun) do(act RuleAction) bool {
+	a, lg := r.a, r.lg
+	switch act.Action {
+	case actionLaptopOn:
+		if a.profileDisablesLaptop() {
+			lg.Debug("[UPDATER]display profile keeps laptop display off", "profile", a.displayProfile.Name)
+			r.laptopOff()
+			break
+		}
+
+		switch {
+		case !a.laptopIsEnabled() || !a.panelsReady():
+			lg.Info("[UPDATER]enabling laptop display")
+			r.restoreWorkspaces = !a.laptopIsEnabled() && a.status() == statusDockedOpened
+			a.enableLaptop(r.tx)
+		case a.laptopNeedsUpdate(r.tx):
+			lg.Info("[UPDATER]updating laptop display settings")
+			r.tx.EnableOrUpdateMonitor(a.desiredLaptop(r.tx))
+		default:
+			lg.Debug("[UPDATER]laptop display already enabled; no action needed")
+		}
+
+	case actionLaptopOff:
+		r.laptopOff()
+
+	case actionLowBattery:
+		r.flush()
+		acted, err := a.runLowBatteryClosedAction(lg)
+		r.setErr(err)
+		return acted
+
+	case actionSuspendClosed:
+		r.flush()
+		if !a.Config.SuspendClosed {
+			return false
+		}
+		if a.Config.SuspendClosedBatteryOnly && a.powerState != power.StateOnBattery {
+			lg.Info("[UPDATER]suspend on closed limited to battery; not suspending")
+			return false
+		}
+		lg.Info("[UPDATER]suspending machine", "action", a.Config.SuspendClosedAction)
+		r.setErr(a.sleep(lg, a.Config.SuspendClosedAction))
+
+	case actionSuspendIdle:
+		r.flush()
+		if a.sleeping {
+			// The sleep inhibitor is released once the update returns, so confirm the laptop
+			// display actually came up before letting the system sleep.
+			if err := a.waitForLaptopEnabled(); err != nil {
+				lg.Error("[UPDATER]laptop display not confirmed enabled before sleep", "error", err)
+			}
+			lg.Info("[UPDATER]system already going to sleep; not suspending")
+			return false
+		}
+		if !a.Config.SuspendIdle {
+			lg.Info("[UPDATER]suspending on idle disabled; doing nothing")
+			return false
+		}
+		lg.Info("[UPDATER]suspending on idle enabled; suspending", "action", a.Config.SuspendIdleAction)
+		r.setErr(a.sleep(lg, a.Config.SuspendIdleAction))
+
+	case actionSuspend:
+		r.flush()
+		lg.Info("[UPDATER]suspending machine", "action", act.Sleep)
+		r.setErr(a.sleep(lg, act.Sleep))
+
+	case actionLock:
+		r.flush()
+		lg.Info("[UPDATER]locking session")
+		if err := a.sleeper.Lock(context.Background()); err != nil {
+			lg.Error("[UPDATER]could not lock session", "error", err)
+			r.setErr(err)
+		}
+
+	case actionRun:
+		r.flush()
+		runPostHook(act.Command, a.hookEnv())
+
+	case actionEnable, actionDisable, actionDPMS, actionPosition:
+		r.monitorAction(act)
+
+	default:
+		lg.Warn("[UPDATER]unknown rule action; skipping", "action", act.Action)
+	}
+
+	return false
+}
+
+// laptopOff takes the laptop display out of use, unless nothing else would be left enabled.
+func (r *ruleRun) laptopOff() {
+	a, lg := r.a, r.lg
+	switch {
+	case !a.anyPanelInUse():
+		lg.Debug("[UPDATER]laptop display already disabled; no action needed")
+	case !a.hasEnabledExternal():
+		// docked by a dock device with no active display; turning off the laptop
+		// display would leave nothing to show
+		lg.Info("[UPDATER]no external display enabled; keeping laptop display enabled")
+	default:
+		cs := a.Config.closedStrategy()
+		lg.Info("[UPDATER]disabling laptop display", "strategy", cs)
+		a.workspacesPending = false
+		a.moveWorkspacesOffLaptop(lg, r.tx)
+		a.disableLaptop(r.tx, cs)
+	}
+}
+
+// monitorAction adds a display action for every monitor matching act.Monitor.
+func (r *ruleRun) monitorAction(act RuleAction) {
+	ms := r.a.matchMonitors(act.Monitor)
+	if len(ms) == 0 {
+		r.lg.Debug("[UPDATER]no monitor matches rule action", "action", act.Action, "monitor", act.Monitor)
+		return
+	}
+
+	for _, m := range ms {
+		switch act.Action {
+		case actionEnable, actionPosition:
+			if act.Position != "" {
+				x, y, err := parsePosition(act.Position)
+				if err != nil {
+					r.lg.Warn("[UPDATER]invalid rule position", "position", act.Position, "error", err)
+					return
+				}
+				m.X, m.Y = x, y
+			} else if act.Action == actionPosition {
+				r.lg.Warn("[UPDATER]position action without a position", "monitor", act.Monitor)
+				return
+			}
+			r.tx.EnableOrUpdateMonitor(m)
+		case actionDisable:
+			r.tx.DisableMonitor(m)
+		case actionDPMS:
+			st := act.State
+			if st == "" {
+				st = "off"
+			}
+			r.tx.Dispatch("dpms", st, m.Name)
+		}
+	}
+}
+
+func (r *ruleRun) setErr(err error) {
+	if err != nil {
+		r.err = err
+	}
+}
+
+// parsePosition parses a "<x>x<y>" position, as in a monitor rule.
+func parsePosition(s string) (int64, int64, error) {
+	xs, ys, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected <x>x<y>, got %q", s)
+	}
+
+	x, err := strconv.ParseInt(xs, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := strconv.ParseInt(ys, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return x, y, nil
+}
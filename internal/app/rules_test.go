@@ -0,0 +1,41 @@
+package app
+
+import "testing"
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"empty", Rule{}, false},
+		{
+			"all fields",
+			Rule{Lid: "closed", Power: "battery", Actions: []RuleAction{
+				{Action: actionLaptopOff},
+				{Action: actionDPMS, Monitor: "DP-1", State: "on"},
+				{Action: actionSuspend, Sleep: "suspend-then-hibernate"},
+				{Action: actionRun, Command: "true"},
+			}},
+			false,
+		},
+		{"unknown lid", Rule{Lid: "shut"}, true},
+		{"unknown power", Rule{Power: "mains"}, true},
+		{"unknown action", Rule{Actions: []RuleAction{{Action: "reboot"}}}, true},
+		{"unknown dpms state", Rule{Actions: []RuleAction{{Action: actionDPMS, State: "standby"}}}, true},
+		{"unknown sleep action", Rule{Actions: []RuleAction{{Action: actionSuspend, Sleep: "freeze"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRules([]Rule{tt.rule})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := validateRules(defaultRules); err != nil {
+		t.Errorf("default rules: %v", err)
+	}
+}
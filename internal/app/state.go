@@ -2,10 +2,9 @@ package app
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
-	"slices"
+	"regexp"
 	"strings"
 
 	"github.com/dsrosen6/hyprdocked/internal/hypr"
@@ -16,25 +15,59 @@ type (
 	// state contains all of the entities that can frequently change.
 	state struct {
 		lidState      power.LidState // current state of laptop lid
+		powerState    power.State    // AC or battery
+		battery       power.Battery  // charge of UPower's display device
+		docks         []string       // names of connected configured dock devices
+		connectors    []string       // DRM connectors with a display physically attached; nil if unknown
 		mode          mode
-		allDisplays   []hypr.Monitor // current displays, returned by hyprctl monitors
-		laptopDisplay hypr.Monitor
+		sleeping      bool           // idle mode was entered because logind is putting the system to sleep
+		allDisplays   []hypr.Monitor // current displays including disabled ones, returned by hyprctl monitors all
+		laptopDisplay hypr.Monitor   // main internal panel
+		panels        []hypr.Monitor // every internal panel, laptopDisplay first
+
+		monitorSet     []string        // sorted descriptions of the connected external monitors
+		profilePending bool            // the display profile is applied on the next update
+		displayProfile *DisplayProfile // active display profile, if any
+
+		mirroring  map[string]hypr.Monitor // externals mirroring the laptop display, with their settings from before
+		mirrorMode *mirrorMode             // laptop display mode while mirrored
+
+		// laptopWorkspaces are the workspaces moved off the internal panels when they were last
+		// disabled.
+		laptopWorkspaces []panelWorkspace
+		// workspacesPending is set while the laptop display is coming up, so its workspaces
+		// are restored once Hyprland reports it enabled.
+		workspacesPending bool
+
+		// dpmsOff holds the panels hyprdocked turned off with DPMS. Anything else can blank a
+		// display too (e.g. hypridle), which doesn't take it out of use.
+		dpmsOff map[string]bool
 	}
 
 	initialStateParams struct {
 		laptopMonitorName string
-		hyprClient        *hypr.Client
-		lidHandler        *power.LidHandler
+		laptopMatch       *regexp.Regexp
+		hyprClient        hypr.Controller
+		lidSource         power.LidSource
+		powerSource       power.Source
+		batterySource     power.BatterySource
+		docks             []DockDevice
+		watchConnectors   bool
+		panels            []InternalPanel
+		sysRoot           string // where sysfs is read from
+	}
+
+	// panelWorkspace is a workspace moved off an internal panel, referenced as a dispatcher
+	// argument.
+	panelWorkspace struct {
+		ref   string
+		panel string
 	}
 
 	// mode is the operating mode of the app.
 	mode int
 )
 
-var commonLaptopDisplays = []string{
-	"edp1",
-}
-
 const (
 	modeNormal mode = iota
 	modeIdle
@@ -64,9 +97,27 @@ func (s *state) ready() bool {
 	return true
 }
 
-func (s *state) laptopIsEnabled() bool {
-	for _, m := range s.allDisplays {
-		if m.Name == s.laptopDisplay.Name {
+// laptopIsEnabled reports whether the main laptop display is in use.
+func (a *App) laptopIsEnabled() bool {
+	m, ok := a.currentLaptop()
+	return ok && a.inUse(m)
+}
+
+// currentLaptop returns the main laptop display as last reported by Hyprland.
+func (s *state) currentLaptop() (hypr.Monitor, bool) {
+	return s.currentDisplay(s.laptopDisplay.Name)
+}
+
+// atOffset reports whether m has been moved to the off-screen position used by the offset
+// closed strategy.
+func atOffset(m hypr.Monitor) bool {
+	return m.X == offsetPosition && m.Y == offsetPosition
+}
+
+// hasEnabledExternal reports whether any display other than the internal panels is enabled.
+func (s *state) hasEnabledExternal() bool {
+	for _, m := range enabledDisplays(s.allDisplays) {
+		if !s.isPanel(m.Name) {
 			return true
 		}
 	}
@@ -74,6 +125,18 @@ func (s *state) laptopIsEnabled() bool {
 	return false
 }
 
+// enabledDisplays filters out monitors Hyprland reports as disabled.
+func enabledDisplays(displays []hypr.Monitor) []hypr.Monitor {
+	var enabled []hypr.Monitor
+	for _, m := range displays {
+		if !m.Disabled {
+			enabled = append(enabled, m)
+		}
+	}
+
+	return enabled
+}
+
 func (m mode) string() string {
 	switch m {
 	case modeNormal:
@@ -90,42 +153,58 @@ func displayReady(m hypr.Monitor) bool {
 }
 
 func getInitialState(ctx context.Context, sp initialStateParams) (*state, error) {
-	ls, err := sp.lidHandler.GetCurrentState(ctx)
+	ls, err := sp.lidSource.GetCurrentState(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting lid status: %w", err)
 	}
 
+	// Power state only refines behavior, so a failure here isn't fatal.
+	ps, err := sp.powerSource.GetCurrentState(ctx)
+	if err != nil {
+		slog.Warn("getting power state", "error", err)
+	}
+
+	bat, err := sp.batterySource.GetCurrentState(ctx)
+	if err != nil {
+		slog.Warn("getting battery state", "error", err)
+	}
+
+	found, err := scanDocks(sp.sysRoot, sp.docks)
+	if err != nil {
+		return nil, fmt.Errorf("scanning for docks: %w", err)
+	}
+
 	ds, err := sp.hyprClient.ListMonitors()
 	if err != nil {
 		return nil, fmt.Errorf("listing displays: %w", err)
 	}
 
-	lm, err := identifyLaptopDisplay(sp.laptopMonitorName, ds)
+	var connectors []string
+	if sp.watchConnectors {
+		if connectors, err = crossCheckConnectors(sp.sysRoot, ds); err != nil {
+			return nil, fmt.Errorf("scanning drm connectors: %w", err)
+		}
+	}
+
+	pc := panelConfig{name: sp.laptopMonitorName, match: sp.laptopMatch, panels: sp.panels}
+	panels, err := identifyPanels(sp.sysRoot, pc, ds)
 	if err != nil {
 		return nil, fmt.Errorf("identifying laptop display: %w", err)
 	}
-	slog.Info("identified laptop display", "name", lm.Name, "desc", lm.Description)
+	logPanels(panels)
 
 	return &state{
 		lidState:      ls,
+		powerState:    ps,
+		battery:       bat,
+		docks:         dockNames(found),
+		connectors:    connectors,
 		allDisplays:   ds,
-		laptopDisplay: lm,
+		laptopDisplay: panels[0],
+		panels:        panels,
 	}, nil
 }
 
-func identifyLaptopDisplay(cfgName string, displays []hypr.Monitor) (hypr.Monitor, error) {
-	for _, m := range displays {
-		trimmed := trimmedDisplayName(m.Name)
-		if slices.Contains(commonLaptopDisplays, trimmed) {
-			return m, nil
-		} else if cfgName != "" && trimmed == cfgName {
-			return m, nil
-		}
-	}
-
-	return hypr.Monitor{}, errors.New("could not identify a laptop display")
-}
-
 func trimmedDisplayName(name string) string {
 	name = strings.ToLower(name)
 	return strings.ReplaceAll(name, "-", "")
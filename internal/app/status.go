@@ -1,7 +1,6 @@
 package app
 
 import (
-	"github.com/dsrosen6/hyprdocked/internal/hypr"
 	"github.com/dsrosen6/hyprdocked/internal/power"
 )
 
@@ -18,7 +17,7 @@ const (
 )
 
 func (a *App) status() status {
-	return getStatus(a.laptopDisplay, a.allDisplays, a.state)
+	return getStatus(a.state)
 }
 
 func (a *App) statusString() string {
@@ -42,16 +41,18 @@ func (s status) string() string {
 	}
 }
 
-func getStatus(laptopDisplay hypr.Monitor, allDisplays []hypr.Monitor, state *state) status {
-	laptopEnabled := false
-	for _, d := range allDisplays {
-		if d.Name == laptopDisplay.Name {
-			laptopEnabled = true
-			break
+func getStatus(state *state) status {
+	// Internal panels never count as docked, however many there are, and neither do displays
+	// Hyprland still reports after the kernel saw them unplugged.
+	externals := 0
+	for _, d := range enabledDisplays(state.allDisplays) {
+		if !state.isPanel(d.Name) && state.physicallyConnected(d.Name) {
+			externals++
 		}
 	}
 
-	if displayReady(laptopDisplay) && (len(allDisplays) == 0 || (len(allDisplays) == 1 && laptopEnabled)) {
+	// A connected dock device means docked, even if none of its displays are active.
+	if displayReady(state.laptopDisplay) && len(state.docks) == 0 && externals == 0 {
 		return laptopOnlyStatus(state.lidState)
 	}
 
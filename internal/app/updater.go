@@ -1,8 +1,15 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"os"
 	"os/exec"
+	"strings"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+	"github.com/dsrosen6/hyprdocked/internal/power"
 )
 
 func (a *App) runUpdater() (bool, error) {
@@ -11,103 +18,160 @@ func (a *App) runUpdater() (bool, error) {
 		a.updating = false
 	}()
 
-	if a.mode == modeIdle {
-		return a.handleIdleCmd()
-	}
-
 	s := a.status()
 	lg := slog.Default().With(
 		slog.String("mode", a.mode.string()),
 		slog.String("status", s.string()),
+		slog.String("power", string(a.powerState)),
+		slog.Any("docks", a.docks),
 	)
 
-	switch s {
-	case statusDockedOpened, statusOnlyLaptopOpened:
-		switch a.laptopIsEnabled() {
-		case true:
-			lg.Debug("[UPDATER]laptop display already enabled; no action needed")
-		case false:
-			lg.Info("[UPDATER]enabling laptop display")
-			return true, a.hctl.EnableOrUpdateMonitor(a.laptopDisplay)
+	if a.mode != modeIdle {
+		a.applyPowerProfile(lg)
+	}
+
+	r, ok := a.matchRule()
+	if !ok {
+		lg.Info("[UPDATER]no rule matches; doing nothing")
+		return false, nil
+	}
+
+	// All display changes of a rule are collected and applied as one batch, so Hyprland never
+	// lays out an intermediate state.
+	lg = lg.With(slog.String("rule", r.Name))
+	return a.runRule(lg, r)
+}
+
+// applyTx applies a batch of display changes and logs each item that failed.
+func (a *App) applyTx(lg *slog.Logger, tx *hypr.Transaction) error {
+	if tx.Len() == 0 {
+		return nil
+	}
+
+	lg.Debug("[UPDATER]applying display changes", "commands", tx.Commands())
+	err := a.hctl.Apply(tx)
+	var txErr *hypr.TxError
+	if errors.As(err, &txErr) {
+		for _, r := range txErr.Failed() {
+			lg.Error("[UPDATER]display change failed", "command", r.Command, "error", r.Err)
 		}
+	}
 
-	case statusOnlyLaptopClosed:
-		changed := false
-		switch a.laptopIsEnabled() {
-		case true:
-			lg.Debug("[UPDATER]laptop display already enabled; no display action needed")
-		case false:
-			lg.Info("[UPDATER]enabling laptop display")
-			if err := a.hctl.EnableOrUpdateMonitor(a.laptopDisplay); err != nil {
-				lg.Error("[UPDATER]issue enabling laptop display", "error", err)
+	return err
+}
+
+// enableLaptop adds whatever is needed to bring each wanted internal panel back from any closed
+// strategy: a monitor rule if it is disabled or parked off-screen, and DPMS on if hyprdocked
+// turned its output off. Panels configured to stay off are disabled.
+func (a *App) enableLaptop(tx *hypr.Transaction) {
+	for _, p := range a.panels {
+		cur, ok := a.currentDisplay(p.Name)
+		if !a.panelWanted(p) {
+			if ok && !cur.Disabled {
+				tx.DisableMonitor(p)
 			}
-			changed = true
+			continue
 		}
 
-		if a.Config.SuspendClosed {
-			lg.Info("[UPDATER]suspending machine")
-			return changed, systemctlSuspend()
+		if !ok || cur.Disabled || atOffset(cur) {
+			tx.EnableOrUpdateMonitor(a.desiredPanel(p, tx))
 		}
-		return changed, nil
-
-	case statusDockedClosed:
-		switch a.laptopIsEnabled() {
-		case true:
-			lg.Info("[UPDATER]disabling laptop display")
-			return true, a.hctl.DisableMonitor(a.laptopDisplay)
-		case false:
-			lg.Debug("[UPDATER]laptop display already disabled; no action needed")
+
+		if ok && !cur.Disabled && !cur.DPMSStatus && a.managesDPMS(p.Name) {
+			tx.Dispatch("dpms", "on", p.Name)
 		}
-	default:
-		lg.Info("[UPDATER]unknown status; doing nothing")
+		delete(a.dpmsOff, p.Name)
 	}
-
-	return false, nil
 }
 
-func (a *App) handleIdleCmd() (bool, error) {
-	changed := false
-	if !a.laptopIsEnabled() {
-		slog.Info("[UPDATER/IDLE CMD]enabling laptop display")
-		if err := a.hctl.EnableOrUpdateMonitor(a.laptopDisplay); err != nil {
-			slog.Error("[UPDATER/IDLE CMD]issue enabling laptop display", "error", err)
+// disableLaptop adds the changes that take every internal panel out of use for the given
+// strategy.
+func (a *App) disableLaptop(tx *hypr.Transaction, cs closedStrategy) {
+	for _, p := range a.panels {
+		if cur, ok := a.currentDisplay(p.Name); ok && cur.Disabled {
+			continue
 		}
-		changed = true
-	} else {
-		slog.Info("[UPDATER/IDLE CMD]laptop display already enabled")
-	}
 
-	if a.Config.SuspendIdle {
-		slog.Info("[UPDATER/IDLE CMD]suspending on idle enabled; suspending")
-		return changed, systemctlSuspend()
+		switch cs {
+		case closedStrategyDPMS:
+			tx.Dispatch("dpms", "off", p.Name)
+			if a.dpmsOff == nil {
+				a.dpmsOff = make(map[string]bool)
+			}
+			a.dpmsOff[p.Name] = true
+		case closedStrategyOffset:
+			// panels are parked on top of each other; none of them is visible anyway
+			m := a.desiredPanel(p, tx)
+			m.X, m.Y = offsetPosition, offsetPosition
+			tx.EnableOrUpdateMonitor(m)
+		default:
+			tx.DisableMonitor(p)
+		}
 	}
-	slog.Info("[UPDATER/IDLE CMD]suspending on idle disabled; doing nothing")
-	return changed, nil
 }
 
 func (a *App) runPostHooks(changed bool) {
+	env := a.hookEnv()
 	for _, hook := range a.Config.PostUpdateHooks {
 		if hook.OnStatusChange && !changed {
 			continue
 		}
+		if hook.Power != "" && power.State(hook.Power) != a.powerState {
+			continue
+		}
 		cmd := hook.Command
 		if a.Config.SequentialHooks {
-			slog.Debug("running post-hook", "command", cmd)
-			if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-				slog.Error("post-hook failed", "command", cmd, "error", err)
-			}
+			runPostHook(cmd, env)
 		} else {
-			go func() {
-				slog.Debug("running post-hook", "command", cmd)
-				if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-					slog.Error("post-hook failed", "command", cmd, "error", err)
-				}
-			}()
+			go runPostHook(cmd, env)
 		}
 	}
 }
 
-func systemctlSuspend() error {
-	cmd := exec.Command("systemctl", "suspend")
-	return cmd.Run()
+func runPostHook(cmd string, env []string) {
+	slog.Debug("running post-hook", "command", cmd)
+	c := exec.Command("sh", "-c", cmd)
+	c.Env = env
+	if err := c.Run(); err != nil {
+		slog.Error("post-hook failed", "command", cmd, "error", err)
+	}
+}
+
+// hookEnv returns the environment post-hooks run with: the daemon's own, plus the current state
+// so hooks can branch on it.
+func (a *App) hookEnv() []string {
+	return append(os.Environ(),
+		"HYPRDOCKED_STATUS="+a.statusString(),
+		"HYPRDOCKED_LID="+string(a.lidState),
+		"HYPRDOCKED_POWER="+string(a.powerState),
+		"HYPRDOCKED_MODE="+a.mode.string(),
+		"HYPRDOCKED_DOCKS="+strings.Join(a.docks, ","),
+	)
+}
+
+// runLowBatteryClosedAction puts the system to sleep if the lid is closed and the battery is low,
+// as set by the battery policy. It reports whether it acted.
+func (a *App) runLowBatteryClosedAction(lg *slog.Logger) (bool, error) {
+	action := a.Config.Battery.ClosedAction
+	if action == "" || !a.batteryLow() {
+		return false, nil
+	}
+
+	lg.Info("[UPDATER]lid closed on low battery", "action", action, "percentage", a.battery.Percentage)
+	return true, a.sleep(lg, action)
+}
+
+// sleep puts the system to sleep through logind with the given action, defaulting to suspend.
+func (a *App) sleep(lg *slog.Logger, action string) error {
+	act := power.SleepAction(action)
+	if act == "" {
+		act = power.SleepSuspend
+	}
+
+	if err := a.sleeper.Sleep(context.Background(), act); err != nil {
+		lg.Error("[UPDATER]could not put system to sleep", "action", act, "error", err)
+		return err
+	}
+
+	return nil
 }
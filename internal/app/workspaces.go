@@ -0,0 +1,160 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+const (
+	monitorWaitTimeout  = 2 * time.Second
+	monitorPollInterval = 100 * time.Millisecond
+)
+
+// moveWorkspacesOffLaptop adds dispatches to tx that move every workspace on the internal panels
+// to an external one, and records them so they can be moved back when the laptop is re-enabled.
+func (a *App) moveWorkspacesOffLaptop(lg *slog.Logger, tx *hypr.Transaction) {
+	if !a.Config.MigrateWorkspaces {
+		return
+	}
+
+	target, ok := a.workspaceTarget()
+	if !ok {
+		lg.Warn("[UPDATER]no external display to move laptop workspaces to")
+		return
+	}
+
+	wss, err := a.hctl.ListWorkspaces()
+	if err != nil {
+		lg.Error("[UPDATER]listing workspaces", "error", err)
+		return
+	}
+
+	var (
+		moved []panelWorkspace
+		refs  []string
+	)
+	for _, ws := range wss {
+		if !a.isPanel(ws.Monitor) || ws.IsSpecial() {
+			continue
+		}
+		moved = append(moved, panelWorkspace{ref: ws.Ref(), panel: ws.Monitor})
+		refs = append(refs, ws.Ref())
+		tx.Dispatch("moveworkspacetomonitor", ws.Ref(), target.Name)
+	}
+
+	if len(moved) == 0 {
+		return
+	}
+
+	lg.Info("[UPDATER]moving laptop workspaces", "workspaces", refs, "target", target.Name)
+	a.laptopWorkspaces = moved
+}
+
+// restorePendingWorkspaces restores the laptop workspaces if that is waiting on the laptop display
+// to come up, and it now has.
+func (a *App) restorePendingWorkspaces(lg *slog.Logger) {
+	if !a.workspacesPending || !a.laptopIsEnabled() {
+		return
+	}
+
+	a.workspacesPending = false
+	if err := a.restoreLaptopWorkspaces(lg); err != nil {
+		lg.Error("[UPDATER]issue restoring laptop workspaces", "error", err)
+	}
+}
+
+// restoreLaptopWorkspaces moves the workspaces recorded by moveWorkspacesOffLaptop back to the
+// panel they came from, or the main laptop display if that panel is off. The record is kept, so
+// the same workspaces follow the laptop on every dock cycle.
+func (a *App) restoreLaptopWorkspaces(lg *slog.Logger) error {
+	if !a.Config.MigrateWorkspaces || len(a.laptopWorkspaces) == 0 {
+		return nil
+	}
+
+	wss, err := a.hctl.ListWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]string, len(wss))
+	for _, ws := range wss {
+		current[ws.Ref()] = ws.Monitor
+	}
+
+	tx := hypr.NewTransaction()
+	var moved []string
+	for _, pw := range a.laptopWorkspaces {
+		panel := pw.panel
+		if cur, ok := a.currentDisplay(panel); !ok || !a.inUse(cur) {
+			panel = a.laptopDisplay.Name
+		}
+
+		mon, ok := current[pw.ref]
+		if !ok || mon == panel {
+			continue
+		}
+		tx.Dispatch("moveworkspacetomonitor", pw.ref, panel)
+		moved = append(moved, pw.ref)
+	}
+
+	if len(moved) == 0 {
+		return nil
+	}
+
+	lg.Info("[UPDATER]restoring laptop workspaces", "workspaces", moved)
+	return a.applyTx(lg, tx)
+}
+
+// workspaceTarget picks the external display laptop workspaces are moved to: the configured
+// workspace target if it is enabled, otherwise the focused external, otherwise the first one.
+func (a *App) workspaceTarget() (hypr.Monitor, bool) {
+	var externals []hypr.Monitor
+	for _, m := range enabledDisplays(a.allDisplays) {
+		if !a.isPanel(m.Name) {
+			externals = append(externals, m)
+		}
+	}
+
+	if len(externals) == 0 {
+		return hypr.Monitor{}, false
+	}
+
+	if t := a.Config.WorkspaceTarget; t != "" {
+		for _, m := range externals {
+			if m.Name == t || m.Description == t {
+				return m, true
+			}
+		}
+	}
+
+	for _, m := range externals {
+		if m.Focused {
+			return m, true
+		}
+	}
+
+	return externals[0], true
+}
+
+func (a *App) waitForLaptopEnabled() error {
+	deadline := time.Now().Add(monitorWaitTimeout)
+	for {
+		ds, err := a.hctl.ListMonitors()
+		if err != nil {
+			return err
+		}
+
+		a.allDisplays = ds
+		if a.laptopIsEnabled() {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for laptop display to be enabled")
+		}
+		time.Sleep(monitorPollInterval)
+	}
+}
@@ -0,0 +1,111 @@
+// Package drm reads display connector state from the kernel's DRM sysfs tree, independent of
+// what the compositor has enabled.
+package drm
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Connector statuses, as written in <connector>/status.
+const (
+	StatusConnected    = "connected"
+	StatusDisconnected = "disconnected"
+	StatusUnknown      = "unknown"
+)
+
+// InternalTypes are the connector types used for built-in panels, the most likely main panel
+// first.
+var InternalTypes = []string{"eDP", "LVDS", "DSI"}
+
+// connectorDir matches connector directories like "card1-eDP-1" or "card0-HDMI-A-1".
+var connectorDir = regexp.MustCompile(`^(card\d+)-(.+)$`)
+
+// Connector is a single display connector of a DRM card.
+type Connector struct {
+	Card    string // e.g. card1
+	Name    string // e.g. eDP-1; matches the monitor name in Hyprland
+	Type    string // e.g. eDP, HDMI-A, DP
+	Status  string
+	Enabled bool
+}
+
+// ScanConnectors reads every connector under <sysRoot>/class/drm.
+func ScanConnectors(sysRoot string) ([]Connector, error) {
+	dir := filepath.Join(sysRoot, "class", "drm")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var cs []Connector
+	for _, e := range entries {
+		m := connectorDir.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		cs = append(cs, Connector{
+			Card:    m[1],
+			Name:    m[2],
+			Type:    connectorType(m[2]),
+			Status:  readAttr(path, "status", StatusUnknown),
+			Enabled: readAttr(path, "enabled", "") == "enabled",
+		})
+	}
+
+	return cs, nil
+}
+
+// Internal reports whether c drives a built-in panel rather than an external output.
+func (c Connector) Internal() bool {
+	return slices.Contains(InternalTypes, c.Type)
+}
+
+// InternalNames returns the names of every internal panel connector, connected or not.
+func InternalNames(cs []Connector) []string {
+	var names []string
+	for _, c := range cs {
+		if c.Internal() {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names
+}
+
+// ConnectedNames returns the names of connectors with a display physically attached.
+func ConnectedNames(cs []Connector) []string {
+	var names []string
+	for _, c := range cs {
+		if c.Status == StatusConnected {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names
+}
+
+// connectorType strips the trailing index from a connector name: "HDMI-A-1" -> "HDMI-A".
+func connectorType(name string) string {
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return name
+	}
+	return name[:i]
+}
+
+func readAttr(dir, name, def string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return def
+	}
+	return strings.TrimSpace(string(b))
+}
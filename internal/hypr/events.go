@@ -0,0 +1,169 @@
+package hypr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	reconnectMinBackoff = 500 * time.Millisecond
+	reconnectMaxBackoff = 10 * time.Second
+)
+
+// SocketConn is a connection to Hyprland's event socket (socket2). It can be re-established
+// with Reconnect if Hyprland restarts.
+type SocketConn struct {
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	closed bool
+}
+
+func NewSocketConn() (*SocketConn, error) {
+	conn, err := dialEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SocketConn{conn: conn}, nil
+}
+
+func (s *SocketConn) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil {
+		return 0, net.ErrClosed
+	}
+	return conn.Read(p)
+}
+
+func (s *SocketConn) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// Reconnect closes the current connection and dials the event socket again, backing off between
+// attempts until it succeeds or ctx is done. If the running Hyprland instance changed, the new
+// instance signature is discovered from $XDG_RUNTIME_DIR/hypr and exported to the environment so
+// the Client and any hooks follow it.
+func (s *SocketConn) Reconnect(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	s.mu.Unlock()
+
+	backoff := reconnectMinBackoff
+	for {
+		if sig, err := discoverInstance(); err != nil {
+			slog.Debug("hyprland instance not found", "error", err)
+		} else {
+			if sig != os.Getenv(sigEnv) {
+				slog.Info("found new hyprland instance", "signature", sig)
+				if err := os.Setenv(sigEnv, sig); err != nil {
+					return fmt.Errorf("setting %s: %w", sigEnv, err)
+				}
+			}
+
+			conn, err := dialEvents()
+			if err == nil {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				if s.closed {
+					_ = conn.Close()
+					return net.ErrClosed
+				}
+				s.conn = conn
+				return nil
+			}
+			slog.Debug("reconnecting to hyprland event socket", "error", err, "retry_in", backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, reconnectMaxBackoff)
+	}
+}
+
+func dialEvents() (*net.UnixConn, error) {
+	dir, err := instanceDir()
+	if err != nil {
+		return nil, err
+	}
+
+	addr := &net.UnixAddr{
+		Name: filepath.Join(dir, sockName),
+		Net:  "unix",
+	}
+
+	conn, err := net.DialUnix("unix", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to socket: %w", err)
+	}
+
+	return conn, nil
+}
+
+// discoverInstance returns the signature of the most recently started Hyprland instance under
+// $XDG_RUNTIME_DIR/hypr. A crashed instance can leave its directory behind, so the newest event
+// socket wins rather than the signature currently in the environment.
+func discoverInstance() (string, error) {
+	runtime := os.Getenv(runtimeEnv)
+	if runtime == "" {
+		return "", errMissingEnvs
+	}
+
+	base := filepath.Join(runtime, "hypr")
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return "", fmt.Errorf("reading hyprland runtime dir: %w", err)
+	}
+
+	var (
+		newest   string
+		newestAt time.Time
+	)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		fi, err := os.Stat(filepath.Join(base, e.Name(), sockName))
+		if err != nil {
+			continue
+		}
+
+		if newest == "" || fi.ModTime().After(newestAt) {
+			newest, newestAt = e.Name(), fi.ModTime()
+		}
+	}
+
+	if newest == "" {
+		return "", errors.New("no running hyprland instance found")
+	}
+
+	return newest, nil
+}
@@ -2,11 +2,12 @@ package hypr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -28,33 +29,58 @@ var (
 )
 
 type (
+	// Client sends requests to Hyprland. It talks to the request socket directly and falls
+	// back to the hyprctl binary if the socket can't be reached and the binary is available.
 	Client struct {
 		binaryPath string
 	}
 
-	Monitor struct {
-		Name        string  `json:"name,omitempty"`
-		Description string  `json:"description,omitempty"`
-		Width       int64   `json:"width,omitempty"`
-		Height      int64   `json:"height,omitempty"`
-		RefreshRate float64 `json:"refreshRate,omitempty"`
-		X           int64   `json:"x,omitempty"`
-		Y           int64   `json:"y,omitempty"`
-		Scale       float64 `json:"scale,omitempty"`
+	// Controller is the set of monitor queries and changes hyprdocked makes. It is implemented
+	// by Client, and by hyprtest.Fake for running without a compositor.
+	Controller interface {
+		ListMonitors() ([]Monitor, error)
+		ListWorkspaces() ([]Workspace, error)
+		EnableOrUpdateMonitor(m Monitor) error
+		DisableMonitor(m Monitor) error
+		Apply(t *Transaction) error
 	}
 
-	SocketConn struct {
-		*net.UnixConn
+	// EventSource is a stream of newline-delimited socket2 events ("EVENT>>DATA") that can be
+	// re-established after it ends. It is implemented by SocketConn.
+	EventSource interface {
+		io.ReadCloser
+		Reconnect(ctx context.Context) error
 	}
 )
 
+var (
+	_ Controller  = (*Client)(nil)
+	_ EventSource = (*SocketConn)(nil)
+)
+
 func NewClient() (*Client, error) {
-	bp, err := exec.LookPath(binaryName)
-	if err != nil {
-		return nil, fmt.Errorf("finding full hyprctl binary path: %w", err)
+	c := &Client{}
+
+	// hyprctl is optional; it is only used as a fallback when the socket is unreachable.
+	if bp, err := exec.LookPath(binaryName); err == nil {
+		c.binaryPath = bp
 	}
 
-	return &Client{binaryPath: bp}, nil
+	if c.sockPath() == "" && c.binaryPath == "" {
+		return nil, errors.New("no hyprland request socket or hyprctl binary available")
+	}
+
+	return c, nil
+}
+
+// sockPath returns the request socket of the current instance. It is resolved on every request
+// so the client follows the event stream to a new instance after Hyprland restarts.
+func (h *Client) sockPath() string {
+	dir, err := instanceDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, reqSockName)
 }
 
 func WaitForEnvs() {
@@ -71,27 +97,6 @@ func WaitForEnvs() {
 	slog.Info("hyprland envs loaded")
 }
 
-func NewSocketConn() (*SocketConn, error) {
-	runtime := os.Getenv(runtimeEnv)
-	sig := os.Getenv(sigEnv)
-	if runtime == "" || sig == "" {
-		return nil, errMissingEnvs
-	}
-
-	sock := filepath.Join(runtime, "hypr", sig, sockName)
-	addr := &net.UnixAddr{
-		Name: sock,
-		Net:  "unix",
-	}
-
-	conn, err := net.DialUnix("unix", nil, addr)
-	if err != nil {
-		return nil, fmt.Errorf("connecting to socket: %w", err)
-	}
-
-	return &SocketConn{conn}, nil
-}
-
 func (h *Client) RunCmdUnmarshal(args []string, v any) error {
 	a := append([]string{"-j"}, args...)
 	out, err := h.RunCmd(a)
@@ -106,7 +111,30 @@ func (h *Client) RunCmdUnmarshal(args []string, v any) error {
 	return nil
 }
 
+// RunCmd runs a request using hyprctl-style args (e.g. "-j", "monitors"), preferring the
+// request socket over the hyprctl binary.
 func (h *Client) RunCmd(args []string) ([]byte, error) {
+	if sp := h.sockPath(); sp != "" {
+		out, err := socketRequest(sp, buildRequest(args))
+		if err == nil {
+			return out, checkForErr(string(out))
+		}
+
+		var de *dialError
+		if !errors.As(err, &de) || h.binaryPath == "" {
+			return nil, err
+		}
+		slog.Debug("hyprland request socket unreachable; falling back to hyprctl", "error", err)
+	}
+
+	return h.runBinary(args)
+}
+
+func (h *Client) runBinary(args []string) ([]byte, error) {
+	if h.binaryPath == "" {
+		return nil, errors.New("hyprctl binary not available")
+	}
+
 	cmd := exec.Command(h.binaryPath, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -125,46 +153,53 @@ func (h *Client) RunCmd(args []string) ([]byte, error) {
 	return out, checkForErr(string(out))
 }
 
-func (h *Client) Reload() error {
-	if _, err := h.RunCmd([]string{"reload"}); err != nil {
-		return err
+// RunBatch sends multiple commands (each in hyprctl-style args, e.g. "keyword", "monitor", "...")
+// as a single batch request. Hyprland splits batches on ';' with no way to escape it, so commands
+// containing one are rejected.
+func (h *Client) RunBatch(cmds [][]string) ([]byte, error) {
+	parts := make([]string, 0, len(cmds))
+	for _, c := range cmds {
+		p := strings.Join(c, " ")
+		if strings.Contains(p, batchSeparator) {
+			return nil, fmt.Errorf("batch command %q contains %q", p, batchSeparator)
+		}
+		parts = append(parts, p)
 	}
-	return nil
+
+	return h.RunCmd([]string{"--batch", strings.Join(parts, batchSeparator+" ")})
 }
 
+// ListMonitors returns all monitors known to Hyprland, including disabled ones. Use the
+// Disabled field to tell them apart.
 func (h *Client) ListMonitors() ([]Monitor, error) {
 	var displays []Monitor
-	if err := h.RunCmdUnmarshal([]string{"monitors"}, &displays); err != nil {
+	if err := h.RunCmdUnmarshal([]string{"monitors", "all"}, &displays); err != nil {
 		return nil, err
 	}
 
+	resolveMirrors(displays)
+	for i := range displays {
+		recoverMode(&displays[i])
+	}
+
 	return displays, nil
 }
 
-func (h *Client) EnableOrUpdateMonitor(m Monitor) error {
-	args := []string{"keyword", "monitor", MonitorToConfigString(m)}
-	if _, err := h.RunCmd(args); err != nil {
-		return err
+func (h *Client) ListWorkspaces() ([]Workspace, error) {
+	var workspaces []Workspace
+	if err := h.RunCmdUnmarshal([]string{"workspaces"}, &workspaces); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return workspaces, nil
 }
 
-func (h *Client) DisableMonitor(m Monitor) error {
-	args := []string{"keyword", "monitor", m.Name + ",", "disable"}
-	if _, err := h.RunCmd(args); err != nil {
-		return err
-	}
-
-	return nil
+func (h *Client) EnableOrUpdateMonitor(m Monitor) error {
+	return h.Apply(NewTransaction().EnableOrUpdateMonitor(m))
 }
 
-func MonitorToConfigString(m Monitor) string {
-	res := fmt.Sprintf("%dx%d", m.Width, m.Height)
-	res = fmt.Sprintf("%s@%f", res, m.RefreshRate)
-	xy := fmt.Sprintf("%dx%d", m.X, m.Y)
-	scale := fmt.Sprintf("%f", m.Scale)
-	return fmt.Sprintf("%s,%s,%s,%s", m.Name, res, xy, scale)
+func (h *Client) DisableMonitor(m Monitor) error {
+	return h.Apply(NewTransaction().DisableMonitor(m))
 }
 
 func checkForErr(out string) error {
@@ -0,0 +1,425 @@
+// Package hyprtest provides an in-memory Hyprland for exercising hyprdocked without a compositor.
+package hyprtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dsrosen6/hyprdocked/internal/hypr"
+)
+
+type (
+	// Fake simulates the monitors and workspaces of a Hyprland instance. Monitor rules and
+	// dispatches applied through it change that state the way Hyprland would, and monitor
+	// changes are emitted on its event stream as socket2 events.
+	Fake struct {
+		mu         sync.Mutex
+		monitors   []hypr.Monitor
+		workspaces []hypr.Workspace
+		nextID     int64
+		applied    []string
+		failures   map[string]error
+		events     *eventStream
+	}
+
+	eventStream struct {
+		mu     sync.Mutex
+		cond   *sync.Cond
+		buf    bytes.Buffer
+		ended  bool
+		closed bool
+	}
+)
+
+var (
+	_ hypr.Controller  = (*Fake)(nil)
+	_ hypr.EventSource = (*eventStream)(nil)
+)
+
+// New returns a Fake with the given monitors connected. Monitors are assigned IDs in order.
+func New(monitors ...hypr.Monitor) *Fake {
+	es := &eventStream{}
+	es.cond = sync.NewCond(&es.mu)
+
+	f := &Fake{
+		failures: make(map[string]error),
+		events:   es,
+	}
+
+	for _, m := range monitors {
+		m.ID = f.nextID
+		m.DPMSStatus = !m.Disabled
+		f.nextID++
+		f.monitors = append(f.monitors, cloneMonitor(m))
+	}
+
+	return f
+}
+
+// Events returns the fake's socket2 event stream.
+func (f *Fake) Events() hypr.EventSource {
+	return f.events
+}
+
+func (f *Fake) ListMonitors() ([]hypr.Monitor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ms := make([]hypr.Monitor, 0, len(f.monitors))
+	for _, m := range f.monitors {
+		ms = append(ms, cloneMonitor(m))
+	}
+
+	return ms, nil
+}
+
+func (f *Fake) ListWorkspaces() ([]hypr.Workspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.workspaces), nil
+}
+
+func (f *Fake) EnableOrUpdateMonitor(m hypr.Monitor) error {
+	return f.Apply(hypr.NewTransaction().EnableOrUpdateMonitor(m))
+}
+
+func (f *Fake) DisableMonitor(m hypr.Monitor) error {
+	return f.Apply(hypr.NewTransaction().DisableMonitor(m))
+}
+
+// Apply runs every item of t in order. Like Hyprland, a failing item doesn't stop the rest.
+func (f *Fake) Apply(t *hypr.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var (
+		results []hypr.TxResult
+		failed  bool
+	)
+	for _, c := range t.Commands() {
+		f.applied = append(f.applied, c)
+		err := f.run(c)
+		if err != nil {
+			failed = true
+		}
+		results = append(results, hypr.TxResult{Command: c, Err: err})
+	}
+
+	if failed {
+		return &hypr.TxError{Results: results}
+	}
+
+	return nil
+}
+
+// Applied returns every command applied so far, in order.
+func (f *Fake) Applied() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.applied)
+}
+
+// FailCommands makes every command starting with prefix fail with err.
+func (f *Fake) FailCommands(prefix string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures[prefix] = err
+}
+
+// AddWorkspace creates a workspace on the named monitor.
+func (f *Fake) AddWorkspace(ws hypr.Workspace, monitor string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ws.Monitor = monitor
+	if m := f.monitor(monitor); m != nil {
+		ws.MonitorID = m.ID
+	}
+	f.workspaces = append(f.workspaces, ws)
+}
+
+// Connect simulates plugging in m. It is enabled immediately and announced on the event stream.
+func (f *Fake) Connect(m hypr.Monitor) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m.ID = f.nextID
+	m.Disabled = false
+	m.DPMSStatus = true
+	f.nextID++
+	f.monitors = append(f.monitors, cloneMonitor(m))
+	f.emitAdded(m)
+}
+
+// Disconnect simulates unplugging the named monitor. Its workspaces move to another enabled
+// monitor, as Hyprland does.
+func (f *Fake) Disconnect(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := slices.IndexFunc(f.monitors, func(m hypr.Monitor) bool { return m.Name == name })
+	if i < 0 {
+		return
+	}
+
+	m := f.monitors[i]
+	f.monitors = slices.Delete(f.monitors, i, i+1)
+	f.evacuateWorkspaces(m.Name)
+	if !m.Disabled {
+		f.emitRemoved(m)
+	}
+}
+
+// Restart ends the current event stream, as if Hyprland exited. Reads return io.EOF until the
+// stream is reconnected.
+func (f *Fake) Restart() {
+	f.events.end()
+}
+
+func (f *Fake) run(cmd string) error {
+	for prefix, err := range f.failures {
+		if strings.HasPrefix(cmd, prefix) {
+			return err
+		}
+	}
+
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 {
+		return errors.New("unknown request")
+	}
+
+	switch fields[0] {
+	case "keyword":
+		if fields[1] != "monitor" {
+			return nil
+		}
+		return f.applyMonitorRule(strings.Join(fields[2:], " "))
+	case "dispatch":
+		return f.dispatch(fields[1], fields[2:])
+	default:
+		return errors.New("unknown request")
+	}
+}
+
+func (f *Fake) applyMonitorRule(rule string) error {
+	r, disabled, err := hypr.ParseMonitorRule(rule)
+	if err != nil {
+		return err
+	}
+
+	m := f.monitor(r.Name)
+	if m == nil {
+		// Hyprland keeps rules for monitors that aren't connected
+		return nil
+	}
+
+	if disabled {
+		if m.Disabled {
+			return nil
+		}
+		m.Disabled = true
+		m.DPMSStatus = false
+		f.evacuateWorkspaces(m.Name)
+		f.emitRemoved(*m)
+		return nil
+	}
+
+	wasDisabled := m.Disabled
+	if r.Width != 0 {
+		m.Width, m.Height, m.RefreshRate = r.Width, r.Height, r.RefreshRate
+	}
+	if r.Scale != 0 {
+		m.Scale = r.Scale
+	}
+	m.X, m.Y = r.X, r.Y
+	m.Transform = r.Transform
+	m.Mirror = r.Mirror
+	m.VRR = r.VRR
+	m.ColorManagementPreset = r.ColorManagementPreset
+	if r.CurrentFormat != "" {
+		m.CurrentFormat = r.CurrentFormat
+	}
+	m.Disabled = false
+	m.DPMSStatus = true
+
+	if wasDisabled {
+		f.emitAdded(*m)
+	}
+
+	return nil
+}
+
+func (f *Fake) dispatch(dispatcher string, args []string) error {
+	switch dispatcher {
+	case "moveworkspacetomonitor":
+		if len(args) != 2 {
+			return fmt.Errorf("moveworkspacetomonitor needs a workspace and a monitor")
+		}
+
+		m := f.monitor(args[1])
+		if m == nil || m.Disabled {
+			return fmt.Errorf("monitor %s not found", args[1])
+		}
+
+		for i, ws := range f.workspaces {
+			if ws.Ref() == args[0] {
+				f.workspaces[i].Monitor = m.Name
+				f.workspaces[i].MonitorID = m.ID
+				return nil
+			}
+		}
+		return fmt.Errorf("workspace %s not found", args[0])
+	case "dpms":
+		return f.setDPMS(args)
+	default:
+		return nil
+	}
+}
+
+// setDPMS handles "dpms on|off|toggle [monitor]". Without a monitor, every enabled one is changed.
+func (f *Fake) setDPMS(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("dpms needs a state")
+	}
+
+	for i := range f.monitors {
+		m := &f.monitors[i]
+		if m.Disabled || (len(args) > 1 && m.Name != args[1]) {
+			continue
+		}
+
+		switch args[0] {
+		case "on":
+			m.DPMSStatus = true
+		case "off":
+			m.DPMSStatus = false
+		case "toggle":
+			m.DPMSStatus = !m.DPMSStatus
+		default:
+			return fmt.Errorf("invalid dpms state %q", args[0])
+		}
+	}
+
+	return nil
+}
+
+// evacuateWorkspaces moves every workspace on the named monitor to the first other enabled one.
+func (f *Fake) evacuateWorkspaces(name string) {
+	var target *hypr.Monitor
+	for i := range f.monitors {
+		if f.monitors[i].Name != name && !f.monitors[i].Disabled {
+			target = &f.monitors[i]
+			break
+		}
+	}
+
+	if target == nil {
+		return
+	}
+
+	for i, ws := range f.workspaces {
+		if ws.Monitor == name {
+			f.workspaces[i].Monitor = target.Name
+			f.workspaces[i].MonitorID = target.ID
+		}
+	}
+}
+
+func (f *Fake) monitor(name string) *hypr.Monitor {
+	for i := range f.monitors {
+		if f.monitors[i].Name == name {
+			return &f.monitors[i]
+		}
+	}
+	return nil
+}
+
+func (f *Fake) emitAdded(m hypr.Monitor) {
+	f.events.emit("monitoradded>>" + m.Name)
+	f.events.emit("monitoraddedv2>>" + v2Data(m))
+}
+
+func (f *Fake) emitRemoved(m hypr.Monitor) {
+	f.events.emit("monitorremoved>>" + m.Name)
+	f.events.emit("monitorremovedv2>>" + v2Data(m))
+}
+
+func v2Data(m hypr.Monitor) string {
+	return strconv.FormatInt(m.ID, 10) + "," + m.Name + "," + m.Description
+}
+
+func cloneMonitor(m hypr.Monitor) hypr.Monitor {
+	m.AvailableModes = slices.Clone(m.AvailableModes)
+	return m
+}
+
+func (e *eventStream) Read(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for e.buf.Len() == 0 && !e.ended && !e.closed {
+		e.cond.Wait()
+	}
+
+	switch {
+	case e.closed:
+		return 0, net.ErrClosed
+	case e.buf.Len() > 0:
+		return e.buf.Read(p)
+	default:
+		return 0, io.EOF
+	}
+}
+
+func (e *eventStream) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	e.cond.Broadcast()
+	return nil
+}
+
+func (e *eventStream) Reconnect(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return net.ErrClosed
+	}
+
+	e.ended = false
+	e.buf.Reset()
+	return ctx.Err()
+}
+
+func (e *eventStream) emit(line string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ended || e.closed {
+		return
+	}
+
+	e.buf.WriteString(line + "\n")
+	e.cond.Broadcast()
+}
+
+func (e *eventStream) end() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.ended = true
+	e.cond.Broadcast()
+}
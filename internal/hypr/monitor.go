@@ -0,0 +1,318 @@
+package hypr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const mirrorNone = "none"
+
+type (
+	// Monitor is a single entry returned by hyprctl monitors -j.
+	Monitor struct {
+		ID                    int64     `json:"id"`
+		Name                  string    `json:"name,omitempty"`
+		Description           string    `json:"description,omitempty"`
+		Make                  string    `json:"make,omitempty"`
+		Model                 string    `json:"model,omitempty"`
+		Serial                string    `json:"serial,omitempty"`
+		Width                 int64     `json:"width,omitempty"`
+		Height                int64     `json:"height,omitempty"`
+		RefreshRate           float64   `json:"refreshRate,omitempty"`
+		X                     int64     `json:"x,omitempty"`
+		Y                     int64     `json:"y,omitempty"`
+		Scale                 float64   `json:"scale,omitempty"`
+		Transform             int       `json:"transform,omitempty"`
+		Focused               bool      `json:"focused,omitempty"`
+		DPMSStatus            bool      `json:"dpmsStatus,omitempty"`
+		VRR                   VRRMode   `json:"vrr,omitempty"`
+		Disabled              bool      `json:"disabled,omitempty"`
+		CurrentFormat         string    `json:"currentFormat,omitempty"`
+		MirrorOf              string    `json:"mirrorOf,omitempty"`
+		AvailableModes        []string  `json:"availableModes,omitempty"`
+		ColorManagementPreset string    `json:"colorManagementPreset,omitempty"`
+		SDRBrightness         float64   `json:"sdrBrightness,omitempty"`
+		SDRSaturation         float64   `json:"sdrSaturation,omitempty"`
+		SDRMinLuminance       float64   `json:"sdrMinLuminance,omitempty"`
+		SDRMaxLuminance       float64   `json:"sdrMaxLuminance,omitempty"`
+		ActiveWorkspace       Workspace `json:"activeWorkspace"`
+
+		// Mirror is the name of the monitor this one mirrors. Hyprland reports mirrorOf as a
+		// monitor ID, so this is filled in from the rest of the monitor list.
+		Mirror string `json:"-"`
+	}
+
+	// VRRMode is a monitor rule's vrr setting. Hyprland reports the active state in monitor
+	// output as a bool, which decodes as VRROff or VRROn.
+	VRRMode int
+
+	// Workspace is a workspace as returned by hyprctl workspaces -j. Monitor output embeds the
+	// same object with only ID and Name set.
+	Workspace struct {
+		ID        int64  `json:"id"`
+		Name      string `json:"name,omitempty"`
+		Monitor   string `json:"monitor,omitempty"`
+		MonitorID int64  `json:"monitorID,omitempty"`
+		Windows   int    `json:"windows,omitempty"`
+	}
+)
+
+// MonitorToConfigString builds a monitor rule (the value of a "monitor =" line) that restores
+// every setting captured in m.
+func MonitorToConfigString(m Monitor) string {
+	res := fmt.Sprintf("%dx%d", m.Width, m.Height)
+	res = fmt.Sprintf("%s@%f", res, m.RefreshRate)
+	xy := fmt.Sprintf("%dx%d", m.X, m.Y)
+	scale := fmt.Sprintf("%f", m.Scale)
+
+	parts := []string{m.Name, res, xy, scale}
+	if m.Transform != 0 {
+		parts = append(parts, "transform", strconv.Itoa(m.Transform))
+	}
+
+	if m.Mirror != "" {
+		parts = append(parts, "mirror", m.Mirror)
+	}
+
+	if bd := formatBitdepth(m.CurrentFormat); bd != 0 {
+		parts = append(parts, "bitdepth", strconv.Itoa(bd))
+	}
+
+	// Only an active VRR state is restored; "off" is the default and writing it explicitly
+	// would override a global misc:vrr setting.
+	if m.VRR != VRROff {
+		parts = append(parts, "vrr", strconv.Itoa(int(m.VRR)))
+	}
+
+	if m.ColorManagementPreset != "" {
+		parts = append(parts, "cm", m.ColorManagementPreset)
+		if isHDRPreset(m.ColorManagementPreset) {
+			parts = append(parts, sdrParts(m)...)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// VRR modes, as in the vrr monitor rule argument.
+const (
+	VRROff        VRRMode = 0
+	VRROn         VRRMode = 1
+	VRRFullscreen VRRMode = 2 // only while a window is fullscreen
+)
+
+// UnmarshalJSON accepts both the bool reported by hyprctl monitors and a numeric mode.
+func (v *VRRMode) UnmarshalJSON(b []byte) error {
+	switch s := string(b); s {
+	case "true":
+		*v = VRROn
+	case "false", "null":
+		*v = VRROff
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid vrr value %s", s)
+		}
+		*v = VRRMode(n)
+	}
+
+	return nil
+}
+
+// Ref returns the identifier to use for w in dispatchers. Named workspaces are referenced by
+// name, since their IDs are negative and not stable.
+func (w Workspace) Ref() string {
+	if w.ID > 0 {
+		return strconv.FormatInt(w.ID, 10)
+	}
+	return "name:" + w.Name
+}
+
+// IsSpecial reports whether w is a special (scratchpad) workspace.
+func (w Workspace) IsSpecial() bool {
+	return strings.HasPrefix(w.Name, "special:")
+}
+
+// ParseMonitorRule is the inverse of MonitorToConfigString. It returns the monitor described by a
+// rule, and whether the rule disables it. Keywords such as "preferred", "auto" leave the matching
+// fields zeroed.
+func ParseMonitorRule(rule string) (m Monitor, disabled bool, err error) {
+	parts := strings.Split(rule, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	m.Name = parts[0]
+	if m.Name == "" {
+		return Monitor{}, false, fmt.Errorf("monitor rule missing name: %q", rule)
+	}
+
+	if len(parts) >= 2 && (parts[1] == "disable" || parts[1] == "disabled") {
+		return m, true, nil
+	}
+
+	if len(parts) < 4 {
+		return Monitor{}, false, fmt.Errorf("monitor rule needs name, resolution, position and scale: %q", rule)
+	}
+
+	if strings.Contains(parts[1], "x") {
+		if m.Width, m.Height, m.RefreshRate, err = ParseMode(parts[1]); err != nil {
+			return Monitor{}, false, err
+		}
+	}
+
+	if xs, ys, ok := strings.Cut(parts[2], "x"); ok {
+		if m.X, err = strconv.ParseInt(xs, 10, 64); err != nil {
+			return Monitor{}, false, fmt.Errorf("invalid monitor rule position: %q", rule)
+		}
+		if m.Y, err = strconv.ParseInt(ys, 10, 64); err != nil {
+			return Monitor{}, false, fmt.Errorf("invalid monitor rule position: %q", rule)
+		}
+	}
+
+	if parts[3] != "auto" {
+		if m.Scale, err = strconv.ParseFloat(parts[3], 64); err != nil {
+			return Monitor{}, false, fmt.Errorf("invalid monitor rule scale: %q", rule)
+		}
+	}
+
+	extra := parts[4:]
+	if len(extra)%2 != 0 {
+		return Monitor{}, false, fmt.Errorf("monitor rule has an argument without a value: %q", rule)
+	}
+
+	for i := 0; i < len(extra); i += 2 {
+		key, val := extra[i], extra[i+1]
+		switch key {
+		case "transform":
+			if m.Transform, err = strconv.Atoi(val); err != nil {
+				return Monitor{}, false, fmt.Errorf("invalid monitor rule transform: %q", rule)
+			}
+		case "mirror":
+			m.Mirror = val
+		case "bitdepth":
+			if val == "10" {
+				m.CurrentFormat = "XRGB2101010"
+			}
+		case "vrr":
+			var v int
+			v, err = strconv.Atoi(val)
+			m.VRR = VRRMode(v)
+		case "cm":
+			m.ColorManagementPreset = val
+		case "sdrbrightness":
+			m.SDRBrightness, err = strconv.ParseFloat(val, 64)
+		case "sdrsaturation":
+			m.SDRSaturation, err = strconv.ParseFloat(val, 64)
+		case "sdr_min_luminance":
+			m.SDRMinLuminance, err = strconv.ParseFloat(val, 64)
+		case "sdr_max_luminance":
+			m.SDRMaxLuminance, err = strconv.ParseFloat(val, 64)
+		}
+
+		if err != nil {
+			return Monitor{}, false, fmt.Errorf("invalid monitor rule %s: %q", key, rule)
+		}
+	}
+
+	return m, false, nil
+}
+
+// resolveMirrors fills in Mirror for each monitor from the mirrorOf ID reported by Hyprland.
+func resolveMirrors(ms []Monitor) {
+	for i := range ms {
+		ref := ms[i].MirrorOf
+		if ref == "" || ref == mirrorNone {
+			continue
+		}
+
+		id, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil {
+			// older Hyprland versions report the name directly
+			ms[i].Mirror = ref
+			continue
+		}
+
+		for _, o := range ms {
+			if o.ID == id {
+				ms[i].Mirror = o.Name
+				break
+			}
+		}
+	}
+}
+
+// recoverMode fills in a usable mode for monitors that report none, which happens when a monitor
+// has been disabled since Hyprland started. The first available mode is Hyprland's preferred one.
+func recoverMode(m *Monitor) {
+	if m.Width == 0 || m.Height == 0 {
+		if len(m.AvailableModes) > 0 {
+			if w, h, r, err := ParseMode(m.AvailableModes[0]); err == nil {
+				m.Width, m.Height, m.RefreshRate = w, h, r
+			}
+		}
+	}
+
+	if m.Scale == 0 {
+		m.Scale = 1
+	}
+}
+
+// ParseMode parses a mode as reported in availableModes, e.g. "1920x1200@60.00Hz".
+func ParseMode(mode string) (width, height int64, refresh float64, err error) {
+	res, rate, _ := strings.Cut(strings.TrimSuffix(mode, "Hz"), "@")
+	ws, hs, ok := strings.Cut(res, "x")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid mode: %q", mode)
+	}
+
+	if width, err = strconv.ParseInt(ws, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid mode width: %q", mode)
+	}
+
+	if height, err = strconv.ParseInt(hs, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid mode height: %q", mode)
+	}
+
+	if rate != "" {
+		if refresh, err = strconv.ParseFloat(rate, 64); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid mode refresh rate: %q", mode)
+		}
+	}
+
+	return width, height, refresh, nil
+}
+
+// formatBitdepth returns 10 for 10-bit DRM formats (e.g. XRGB2101010), or 0 for the default 8-bit.
+func formatBitdepth(format string) int {
+	if strings.Contains(format, "2101010") {
+		return 10
+	}
+	return 0
+}
+
+func isHDRPreset(preset string) bool {
+	return preset == "hdr" || preset == "hdredid"
+}
+
+func sdrParts(m Monitor) []string {
+	var parts []string
+	if m.SDRBrightness != 0 {
+		parts = append(parts, "sdrbrightness", strconv.FormatFloat(m.SDRBrightness, 'f', -1, 64))
+	}
+
+	if m.SDRSaturation != 0 {
+		parts = append(parts, "sdrsaturation", strconv.FormatFloat(m.SDRSaturation, 'f', -1, 64))
+	}
+
+	if m.SDRMinLuminance != 0 {
+		parts = append(parts, "sdr_min_luminance", strconv.FormatFloat(m.SDRMinLuminance, 'f', -1, 64))
+	}
+
+	if m.SDRMaxLuminance != 0 {
+		parts = append(parts, "sdr_max_luminance", strconv.FormatFloat(m.SDRMaxLuminance, 'f', -1, 64))
+	}
+
+	return parts
+}
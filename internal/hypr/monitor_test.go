@@ -0,0 +1,91 @@
+package hypr
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMonitorRuleRoundTrip(t *testing.T) {
+	base := Monitor{Name: "DP-1", Width: 2560, Height: 1440, RefreshRate: 143.912, X: 1920, Y: -200, Scale: 1.25}
+
+	tests := []struct {
+		name string
+		edit func(m *Monitor)
+	}{
+		{"mode position scale", func(m *Monitor) {}},
+		{"transform", func(m *Monitor) { m.Transform = 3 }},
+		{"mirror", func(m *Monitor) { m.Mirror = "eDP-1" }},
+		{"bitdepth", func(m *Monitor) { m.CurrentFormat = "XRGB2101010" }},
+		{"vrr on", func(m *Monitor) { m.VRR = VRROn }},
+		{"vrr fullscreen", func(m *Monitor) { m.VRR = VRRFullscreen }},
+		{"cm", func(m *Monitor) { m.ColorManagementPreset = "wide" }},
+		{"cm hdr with sdr", func(m *Monitor) {
+			m.ColorManagementPreset = "hdr"
+			m.SDRBrightness = 1.2
+			m.SDRSaturation = 0.98
+			m.SDRMinLuminance = 0.005
+			m.SDRMaxLuminance = 250
+		}},
+		{"everything", func(m *Monitor) {
+			m.Transform = 1
+			m.Mirror = "HDMI-A-1"
+			m.CurrentFormat = "XRGB2101010"
+			m.VRR = VRRFullscreen
+			m.ColorManagementPreset = "hdredid"
+			m.SDRBrightness = 1.1
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := base
+			tt.edit(&want)
+
+			rule := MonitorToConfigString(want)
+			got, disabled, err := ParseMonitorRule(rule)
+			if err != nil {
+				t.Fatalf("ParseMonitorRule(%q): %v", rule, err)
+			}
+			if disabled {
+				t.Fatalf("ParseMonitorRule(%q) reported disabled", rule)
+			}
+
+			// the rule carries the refresh rate with six decimals
+			if d := got.RefreshRate - want.RefreshRate; d > 1e-6 || d < -1e-6 {
+				t.Errorf("refresh rate = %v, want %v", got.RefreshRate, want.RefreshRate)
+			}
+			got.RefreshRate = want.RefreshRate
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip of %q:\n got %+v\nwant %+v", rule, got, want)
+			}
+		})
+	}
+}
+
+func TestParseMonitorRuleDisabled(t *testing.T) {
+	m, disabled, err := ParseMonitorRule("eDP-1,disable")
+	if err != nil || !disabled || m.Name != "eDP-1" {
+		t.Fatalf("got %+v, %v, %v", m, disabled, err)
+	}
+}
+
+func TestVRRModeUnmarshal(t *testing.T) {
+	tests := map[string]VRRMode{
+		`{"vrr":true}`:  VRROn,
+		`{"vrr":false}`: VRROff,
+		`{"vrr":2}`:     VRRFullscreen,
+		`{}`:            VRROff,
+	}
+
+	for in, want := range tests {
+		var m Monitor
+		if err := json.Unmarshal([]byte(in), &m); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if m.VRR != want {
+			t.Errorf("unmarshal %s: vrr = %d, want %d", in, m.VRR, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package hypr
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	reqSockName    = ".socket.sock"
+	batchPrefix    = "[[BATCH]]"
+	batchSeparator = ";"
+	reqTimeout     = 5 * time.Second
+)
+
+// instanceDir returns the runtime directory of the current Hyprland instance.
+func instanceDir() (string, error) {
+	runtime := os.Getenv(runtimeEnv)
+	sig := os.Getenv(sigEnv)
+	if runtime == "" || sig == "" {
+		return "", errMissingEnvs
+	}
+
+	return filepath.Join(runtime, "hypr", sig), nil
+}
+
+// socketRequest sends a single request to Hyprland's request socket and returns the full reply.
+// Hyprland closes the connection after replying, so the reply is read until EOF.
+func socketRequest(sockPath, req string) ([]byte, error) {
+	conn, err := net.DialTimeout("unix", sockPath, reqTimeout)
+	if err != nil {
+		return nil, &dialError{err: err}
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err := conn.SetDeadline(time.Now().Add(reqTimeout)); err != nil {
+		return nil, fmt.Errorf("setting socket deadline: %w", err)
+	}
+
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return nil, fmt.Errorf("writing request: %w", err)
+	}
+
+	out, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("reading reply: %w", err)
+	}
+
+	return out, nil
+}
+
+// buildRequest converts hyprctl-style arguments into the raw string Hyprland expects on its
+// request socket. Leading -j and --batch flags are translated the same way hyprctl does it:
+// flags become a "j/" prefix, and batches are prefixed with [[BATCH]] and split on ';'.
+func buildRequest(args []string) string {
+	var (
+		flags string
+		batch bool
+		i     int
+	)
+
+flagLoop:
+	for ; i < len(args); i++ {
+		switch args[i] {
+		case "-j":
+			flags += "j"
+		case "--batch":
+			batch = true
+		default:
+			break flagLoop
+		}
+	}
+
+	body := strings.Join(args[i:], " ")
+	if !batch {
+		return withFlags(flags, body)
+	}
+
+	var cmds []string
+	for c := range strings.SplitSeq(body, batchSeparator) {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		cmds = append(cmds, withFlags(flags, c))
+	}
+
+	return batchPrefix + strings.Join(cmds, batchSeparator)
+}
+
+func withFlags(flags, req string) string {
+	if flags == "" {
+		return req
+	}
+	return flags + "/" + req
+}
+
+// dialError marks a failure to reach the request socket at all, as opposed to a failed request.
+// Only dial errors trigger the hyprctl binary fallback.
+type dialError struct {
+	err error
+}
+
+func (e *dialError) Error() string {
+	return fmt.Sprintf("connecting to request socket: %v", e.err)
+}
+
+func (e *dialError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,41 @@
+package hypr
+
+import "testing"
+
+func TestBuildRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"plain", []string{"monitors"}, "monitors"},
+		{"args joined", []string{"keyword", "monitor", "DP-1,2560x1440@60,0x0,1"}, "keyword monitor DP-1,2560x1440@60,0x0,1"},
+		{"json", []string{"-j", "monitors"}, "j/monitors"},
+		{"json flag only leading", []string{"monitors", "-j"}, "monitors -j"},
+		{
+			"batch",
+			[]string{"--batch", "keyword monitor eDP-1,disable; dispatch dpms off DP-1"},
+			"[[BATCH]]keyword monitor eDP-1,disable;dispatch dpms off DP-1",
+		},
+		{"batch drops empty commands", []string{"--batch", " ; monitors;; workspaces ;"}, "[[BATCH]]monitors;workspaces"},
+		{"json batch", []string{"-j", "--batch", "monitors; workspaces"}, "[[BATCH]]j/monitors;j/workspaces"},
+		{"batch then json", []string{"--batch", "-j", "monitors"}, "[[BATCH]]j/monitors"},
+		{"empty batch", []string{"--batch"}, "[[BATCH]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildRequest(tt.args); got != tt.want {
+				t.Errorf("buildRequest(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBatchRejectsSeparator(t *testing.T) {
+	// rejected before anything is sent, so no Hyprland instance is needed
+	h := &Client{}
+	if _, err := h.RunBatch([][]string{{"dispatch", "exec", "notify-send docked; true"}}); err == nil {
+		t.Error("RunBatch with ';' in an argument succeeded, want error")
+	}
+}
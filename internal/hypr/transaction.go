@@ -0,0 +1,202 @@
+package hypr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	okReply             = "ok"
+	batchReplySeparator = "\n\n"
+)
+
+type (
+	// Transaction is an ordered list of monitor and dispatch changes that are sent to Hyprland
+	// as a single batch request, so they are applied together without intermediate layouts.
+	Transaction struct {
+		items    [][]string
+		monitors map[string]Monitor // last monitor rule queued for each name
+	}
+
+	// TxResult is the outcome of a single item in an applied Transaction.
+	TxResult struct {
+		Command string
+		Err     error
+	}
+
+	// TxError is returned by Apply when one or more items in a Transaction failed.
+	TxError struct {
+		Results []TxResult
+	}
+)
+
+func NewTransaction() *Transaction {
+	return &Transaction{}
+}
+
+// EnableOrUpdateMonitor adds a monitor rule built from m.
+func (t *Transaction) EnableOrUpdateMonitor(m Monitor) *Transaction {
+	t.queue(m)
+	return t.Keyword("monitor", MonitorToConfigString(m))
+}
+
+// DisableMonitor adds a rule disabling m.
+func (t *Transaction) DisableMonitor(m Monitor) *Transaction {
+	m.Disabled = true
+	t.queue(m)
+	return t.Keyword("monitor", m.Name+",disable")
+}
+
+func (t *Transaction) queue(m Monitor) {
+	if t.monitors == nil {
+		t.monitors = make(map[string]Monitor)
+	}
+	t.monitors[m.Name] = m
+}
+
+// QueuedMonitor returns the settings the last monitor rule in t gives the named monitor, so
+// later changes in the same transaction can build on them before Hyprland reports them.
+func (t *Transaction) QueuedMonitor(name string) (Monitor, bool) {
+	if t == nil {
+		return Monitor{}, false
+	}
+
+	m, ok := t.monitors[name]
+	return m, ok
+}
+
+// Keyword adds a "keyword <args...>" item.
+func (t *Transaction) Keyword(args ...string) *Transaction {
+	t.items = append(t.items, append([]string{"keyword"}, args...))
+	return t
+}
+
+// Dispatch adds a "dispatch <args...>" item, e.g. Dispatch("moveworkspacetomonitor", "1", "DP-1").
+func (t *Transaction) Dispatch(args ...string) *Transaction {
+	t.items = append(t.items, append([]string{"dispatch"}, args...))
+	return t
+}
+
+func (t *Transaction) Len() int {
+	return len(t.items)
+}
+
+// Commands returns each item as the command string sent to Hyprland.
+func (t *Transaction) Commands() []string {
+	cmds := make([]string, 0, len(t.items))
+	for _, it := range t.items {
+		cmds = append(cmds, strings.Join(it, " "))
+	}
+
+	return cmds
+}
+
+// Apply sends every item in t as one batch request. If any item fails, the returned error is a
+// *TxError holding the result of each item.
+func (h *Client) Apply(t *Transaction) error {
+	if t == nil || t.Len() == 0 {
+		return nil
+	}
+
+	out, err := h.RunBatch(t.items)
+	if err != nil {
+		return fmt.Errorf("running batch: %w", err)
+	}
+
+	results := parseBatchReply(t.Commands(), string(out))
+	for _, r := range results {
+		if r.Err != nil {
+			return &TxError{Results: results}
+		}
+	}
+
+	return nil
+}
+
+func (e *TxError) Error() string {
+	var failed []string
+	for _, r := range e.Results {
+		if r.Err != nil {
+			failed = append(failed, fmt.Sprintf("%q: %v", r.Command, r.Err))
+		}
+	}
+
+	return fmt.Sprintf("%d of %d batch items failed: %s", len(failed), len(e.Results), strings.Join(failed, "; "))
+}
+
+// Failed returns only the results that have an error.
+func (e *TxError) Failed() []TxResult {
+	var failed []TxResult
+	for _, r := range e.Results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+
+	return failed
+}
+
+// parseBatchReply maps a batch reply back onto the commands that produced it, in order.
+// Successful keywords and dispatches reply "ok". Recent Hyprland versions end each item's reply
+// with a blank line, so the reply splits into one segment per command. Older versions concatenate
+// the replies with no separator; then each command either consumes a leading "ok", or takes the
+// error text up to the next "ok" as its own.
+func parseBatchReply(cmds []string, reply string) []TxResult {
+	results := make([]TxResult, len(cmds))
+	for i, c := range cmds {
+		results[i].Command = c
+	}
+
+	segs := splitBatchReply(reply)
+	if len(segs) != len(cmds) {
+		segs = scanBatchReply(reply, len(cmds))
+	}
+
+	for i, seg := range segs {
+		if seg == okReply {
+			continue
+		}
+		if seg == "" {
+			seg = "no reply"
+		}
+		results[i].Err = errors.New(seg)
+	}
+
+	return results
+}
+
+// splitBatchReply splits a reply on the blank lines between items.
+func splitBatchReply(reply string) []string {
+	var segs []string
+	for seg := range strings.SplitSeq(strings.TrimSpace(reply), batchReplySeparator) {
+		segs = append(segs, strings.TrimSpace(seg))
+	}
+
+	return segs
+}
+
+// scanBatchReply splits a reply with no separators into n segments.
+func scanBatchReply(reply string, n int) []string {
+	segs := make([]string, n)
+	rest := strings.TrimSpace(reply)
+	for i := range segs {
+		if strings.HasPrefix(rest, okReply) {
+			segs[i] = okReply
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, okReply))
+			continue
+		}
+
+		// the last item owns whatever is left
+		end := len(rest)
+		if i < n-1 {
+			if j := strings.Index(rest, okReply); j >= 0 {
+				end = j
+			}
+		}
+		segs[i] = strings.TrimSpace(rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+
+	return segs
+}
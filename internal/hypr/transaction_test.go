@@ -0,0 +1,78 @@
+package hypr
+
+import "testing"
+
+func TestParseBatchReply(t *testing.T) {
+	cmds := []string{"keyword monitor a", "keyword monitor b", "dispatch dpms off c", "dispatch dpms on d"}
+
+	tests := []struct {
+		name  string
+		reply string
+		want  []string // error text per command; "" for success
+	}{
+		{"all ok separated", "ok\n\nok\n\nok\n\nok\n\n", []string{"", "", "", ""}},
+		{"all ok concatenated", "okokokok", []string{"", "", "", ""}},
+		{
+			"non-adjacent failures separated",
+			"invalid monitor a\n\nok\n\nno such monitor c\n\nok\n\n",
+			[]string{"invalid monitor a", "", "no such monitor c", ""},
+		},
+		{
+			"non-adjacent failures concatenated",
+			"invalid monitor aokno such monitor cok",
+			[]string{"invalid monitor a", "", "no such monitor c", ""},
+		},
+		{
+			"last fails concatenated",
+			"okokokbad dispatcher",
+			[]string{"", "", "", "bad dispatcher"},
+		},
+		{"empty reply", "", []string{"no reply", "no reply", "no reply", "no reply"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBatchReply(cmds, tt.reply)
+			if len(got) != len(cmds) {
+				t.Fatalf("got %d results, want %d", len(got), len(cmds))
+			}
+
+			for i, r := range got {
+				if r.Command != cmds[i] {
+					t.Errorf("result %d command = %q, want %q", i, r.Command, cmds[i])
+				}
+
+				var msg string
+				if r.Err != nil {
+					msg = r.Err.Error()
+				}
+				if msg != tt.want[i] {
+					t.Errorf("result %d (%s) error = %q, want %q", i, cmds[i], msg, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionQueuedMonitor(t *testing.T) {
+	var nilTx *Transaction
+	if _, ok := nilTx.QueuedMonitor("DP-1"); ok {
+		t.Error("nil transaction reported a queued monitor")
+	}
+
+	tx := NewTransaction()
+	tx.EnableOrUpdateMonitor(Monitor{Name: "DP-1", X: 0})
+	tx.Dispatch("dpms", "on", "eDP-1")
+	tx.EnableOrUpdateMonitor(Monitor{Name: "DP-1", X: 2560})
+	tx.DisableMonitor(Monitor{Name: "HDMI-A-1"})
+
+	if m, ok := tx.QueuedMonitor("DP-1"); !ok || m.X != 2560 {
+		t.Errorf("DP-1 = %+v, %v; want the last rule at x 2560", m, ok)
+	}
+	if m, ok := tx.QueuedMonitor("HDMI-A-1"); !ok || !m.Disabled {
+		t.Errorf("HDMI-A-1 = %+v, %v; want disabled", m, ok)
+	}
+	if _, ok := tx.QueuedMonitor("eDP-1"); ok {
+		t.Error("dispatch reported as a queued monitor")
+	}
+}
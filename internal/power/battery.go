@@ -0,0 +1,186 @@
+package power
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	displayDevicePath = "/org/freedesktop/UPower/devices/DisplayDevice"
+	deviceIfc         = "org.freedesktop.UPower.Device"
+	getAllMethod      = "org.freedesktop.DBus.Properties.GetAll"
+
+	percentageProperty  = "Percentage"
+	stateProperty       = "State"
+	timeToEmptyProperty = "TimeToEmpty"
+	isPresentProperty   = "IsPresent"
+)
+
+var batteryProperties = []string{percentageProperty, stateProperty, timeToEmptyProperty, isPresentProperty}
+
+type (
+	// BatterySource reports the charge of the system's batteries. Changes receives a value
+	// whenever it may have changed, while ListenForChanges is running.
+	BatterySource interface {
+		GetCurrentState(ctx context.Context) (Battery, error)
+		ListenForChanges(ctx context.Context) error
+		Changes() <-chan struct{}
+	}
+
+	// BatteryHandler tracks UPower's DisplayDevice, the composite of all batteries that UPower
+	// exposes for display in panels.
+	BatteryHandler struct {
+		conn    *dbus.Conn
+		Events  chan struct{}
+		signals chan *dbus.Signal
+	}
+
+	Battery struct {
+		Present     bool
+		Percentage  float64
+		State       BatteryState
+		TimeToEmpty time.Duration
+	}
+
+	BatteryState string
+)
+
+// Battery states, as enumerated by UPower's Device.State.
+const (
+	BatteryStateUnknown          BatteryState = "unknown"
+	BatteryStateCharging         BatteryState = "charging"
+	BatteryStateDischarging      BatteryState = "discharging"
+	BatteryStateEmpty            BatteryState = "empty"
+	BatteryStateFullyCharged     BatteryState = "fully_charged"
+	BatteryStatePendingCharge    BatteryState = "pending_charge"
+	BatteryStatePendingDischarge BatteryState = "pending_discharge"
+)
+
+var upowerBatteryStates = map[uint32]BatteryState{
+	1: BatteryStateCharging,
+	2: BatteryStateDischarging,
+	3: BatteryStateEmpty,
+	4: BatteryStateFullyCharged,
+	5: BatteryStatePendingCharge,
+	6: BatteryStatePendingDischarge,
+}
+
+func NewBatteryHandler(conn *dbus.Conn) *BatteryHandler {
+	return &BatteryHandler{
+		conn:    conn,
+		Events:  make(chan struct{}, 10),
+		signals: make(chan *dbus.Signal, 10),
+	}
+}
+
+func (b *BatteryHandler) Changes() <-chan struct{} {
+	return b.Events
+}
+
+func (b *BatteryHandler) ListenForChanges(ctx context.Context) error {
+	defer close(b.Events)
+	defer b.conn.RemoveSignal(b.signals)
+	if err := b.startDbus(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case sig, ok := <-b.signals:
+			if !ok {
+				return fmt.Errorf("signals channel closed")
+			}
+
+			if !b.shouldHandleSignal(sig) {
+				continue
+			}
+
+			select {
+			case b.Events <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *BatteryHandler) startDbus(ctx context.Context) error {
+	if err := b.conn.AddMatchSignalContext(
+		ctx, dbus.WithMatchInterface(upowerMatchIfc), dbus.WithMatchMember(upowerMatchMbr),
+		dbus.WithMatchObjectPath(dbus.ObjectPath(displayDevicePath)),
+	); err != nil {
+		return fmt.Errorf("failed to add dbus match rule: %w", err)
+	}
+
+	b.conn.Signal(b.signals)
+	return nil
+}
+
+func (b *BatteryHandler) GetCurrentState(ctx context.Context) (Battery, error) {
+	obj := b.conn.Object(upowerDest, displayDevicePath)
+	var props map[string]dbus.Variant
+	if err := obj.CallWithContext(ctx, getAllMethod, 0, deviceIfc).Store(&props); err != nil {
+		return Battery{State: BatteryStateUnknown}, err
+	}
+
+	return batteryFromProps(props), nil
+}
+
+func batteryFromProps(props map[string]dbus.Variant) Battery {
+	bat := Battery{State: BatteryStateUnknown}
+	if v, ok := props[isPresentProperty].Value().(bool); ok {
+		bat.Present = v
+	}
+
+	if v, ok := props[percentageProperty].Value().(float64); ok {
+		bat.Percentage = v
+	}
+
+	if v, ok := props[stateProperty].Value().(uint32); ok {
+		if st, ok := upowerBatteryStates[v]; ok {
+			bat.State = st
+		}
+	}
+
+	if v, ok := props[timeToEmptyProperty].Value().(int64); ok {
+		bat.TimeToEmpty = time.Duration(v) * time.Second
+	}
+
+	return bat
+}
+
+func (b *BatteryHandler) shouldHandleSignal(sig *dbus.Signal) bool {
+	if sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || sig.Path != displayDevicePath {
+		return false
+	}
+
+	if len(sig.Body) < 2 {
+		return false
+	}
+
+	if changedProps, ok := sig.Body[1].(map[string]dbus.Variant); ok {
+		for _, p := range batteryProperties {
+			if _, exists := changedProps[p]; exists {
+				return true
+			}
+		}
+	}
+
+	if len(sig.Body) >= 3 {
+		if invalidated, ok := sig.Body[2].([]string); ok {
+			for _, p := range batteryProperties {
+				if slices.Contains(invalidated, p) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package power
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	inhibitMethod = logindManagerIfc + ".Inhibit"
+	inhibitWho    = "hyprdocked"
+	inhibitWhy    = "Re-enabling the laptop display before sleep"
+)
+
+// Inhibitor holds off sleep while it is held.
+type Inhibitor interface {
+	Acquire(ctx context.Context) error
+	Release() error
+	Held() bool
+}
+
+// SleepInhibitor holds a logind "delay" sleep inhibitor lock. While it is held, logind waits
+// (up to InhibitDelayMaxSec) after sending PrepareForSleep before the system actually sleeps.
+type SleepInhibitor struct {
+	conn *dbus.Conn
+	mu   sync.Mutex
+	fd   *os.File
+}
+
+func NewSleepInhibitor(conn *dbus.Conn) *SleepInhibitor {
+	return &SleepInhibitor{conn: conn}
+}
+
+// Acquire takes the lock. It does nothing if the lock is already held.
+func (i *SleepInhibitor) Acquire(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.fd != nil {
+		return nil
+	}
+
+	obj := i.conn.Object(logindDest, logindPath)
+	var fd dbus.UnixFD
+	if err := obj.CallWithContext(ctx, inhibitMethod, 0, "sleep", inhibitWho, inhibitWhy, "delay").Store(&fd); err != nil {
+		return fmt.Errorf("taking sleep inhibitor lock: %w", err)
+	}
+
+	i.fd = os.NewFile(uintptr(fd), "logind-inhibitor")
+	return nil
+}
+
+// Release lets the system go to sleep. It does nothing if the lock isn't held.
+func (i *SleepInhibitor) Release() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.fd == nil {
+		return nil
+	}
+
+	err := i.fd.Close()
+	i.fd = nil
+	if err != nil {
+		return fmt.Errorf("releasing sleep inhibitor lock: %w", err)
+	}
+
+	return nil
+}
+
+func (i *SleepInhibitor) Held() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.fd != nil
+}
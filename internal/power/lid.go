@@ -13,6 +13,16 @@ const (
 )
 
 type (
+	// LidSource reports the state of the laptop lid. Changes receives a value whenever the state
+	// may have changed, while ListenForChanges is running; the new state is read with
+	// GetCurrentState.
+	LidSource interface {
+		GetCurrentState(ctx context.Context) (LidState, error)
+		ListenForChanges(ctx context.Context) error
+		Changes() <-chan struct{}
+	}
+
+	// LidHandler is the UPower lid source, using its LidIsClosed property.
 	LidHandler struct {
 		conn    *dbus.Conn
 		Events  chan struct{}
@@ -36,6 +46,10 @@ func NewLidHandler(conn *dbus.Conn) *LidHandler {
 	}
 }
 
+func (l *LidHandler) Changes() <-chan struct{} {
+	return l.Events
+}
+
 func (l *LidHandler) ListenForChanges(ctx context.Context) error {
 	defer close(l.Events)
 	defer l.conn.RemoveSignal(l.signals)
@@ -80,7 +94,7 @@ func (l *LidHandler) startDbus(ctx context.Context) error {
 func (l *LidHandler) GetCurrentState(ctx context.Context) (LidState, error) {
 	obj := l.conn.Object(upowerDest, upowerPath)
 	var result dbus.Variant
-	if err := obj.CallWithContext(ctx, upowerMethod, 0, upowerDest, lidProperty).Store(&result); err != nil {
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, upowerDest, lidProperty).Store(&result); err != nil {
 		return LidStateUnknown, err
 	}
 
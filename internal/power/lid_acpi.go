@@ -0,0 +1,71 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultACPILidDir = "/proc/acpi/button/lid"
+
+// ACPILid reads the lid state from the ACPI button driver's state files
+// (<dir>/*/state, containing e.g. "state:      open"). Changes are polled.
+type ACPILid struct {
+	dir    string
+	Events chan struct{}
+}
+
+// NewACPILid returns an ACPI lid source reading from dir, normally /proc/acpi/button/lid.
+func NewACPILid(dir string) *ACPILid {
+	return &ACPILid{
+		dir:    dir,
+		Events: make(chan struct{}, 10),
+	}
+}
+
+func (l *ACPILid) Changes() <-chan struct{} {
+	return l.Events
+}
+
+func (l *ACPILid) ListenForChanges(ctx context.Context) error {
+	defer close(l.Events)
+	return pollLid(ctx, l.GetCurrentState, l.Events)
+}
+
+// GetCurrentState reads the first lid's state file. Laptops only have one lid (usually LID0).
+func (l *ACPILid) GetCurrentState(_ context.Context) (LidState, error) {
+	files, err := filepath.Glob(filepath.Join(l.dir, "*", "state"))
+	if err != nil {
+		return LidStateUnknown, err
+	}
+
+	if len(files) == 0 {
+		return LidStateUnknown, errors.New("no acpi lid state file found")
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		return LidStateUnknown, fmt.Errorf("reading acpi lid state: %w", err)
+	}
+
+	return parseACPILidState(string(b))
+}
+
+func parseACPILidState(content string) (LidState, error) {
+	_, val, ok := strings.Cut(content, ":")
+	if !ok {
+		return LidStateUnknown, fmt.Errorf("unexpected acpi lid state: %q", content)
+	}
+
+	switch strings.TrimSpace(val) {
+	case "open":
+		return LidStateOpened, nil
+	case "closed":
+		return LidStateClosed, nil
+	default:
+		return LidStateUnknown, fmt.Errorf("unexpected acpi lid state: %q", content)
+	}
+}
@@ -0,0 +1,184 @@
+package power
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	defaultSysInputDir = "/sys/class/input"
+	defaultDevInputDir = "/dev/input"
+
+	evSW  = 0x05 // EV_SW event type
+	swLid = 0x00 // SW_LID switch code
+
+	// eviocgsw is EVIOCGSW(8): _IOC(_IOC_READ, 'E', 0x1b, 8), which reads the switch states.
+	eviocgsw = 2<<30 | 8<<16 | 'E'<<8 | 0x1b
+)
+
+// inputEventSize is sizeof(struct input_event): a timeval followed by type, code and value.
+var inputEventSize = int(unsafe.Sizeof(syscall.Timeval{})) + 8
+
+// EvdevLid reads SW_LID switch events from an evdev input device, such as the "Lid Switch"
+// device under /dev/input.
+type EvdevLid struct {
+	path   string
+	Events chan struct{}
+
+	mu   sync.Mutex
+	last LidState // last state seen in an event, used when the device can't be queried
+}
+
+func NewEvdevLid(path string) *EvdevLid {
+	return &EvdevLid{
+		path:   path,
+		Events: make(chan struct{}, 10),
+		last:   LidStateUnknown,
+	}
+}
+
+func (l *EvdevLid) Changes() <-chan struct{} {
+	return l.Events
+}
+
+// GetCurrentState queries the device's switch states. If the path isn't a real input device
+// (e.g. a pipe), the state from the last SW_LID event is returned instead, or an error if there
+// hasn't been one yet.
+func (l *EvdevLid) GetCurrentState(_ context.Context) (LidState, error) {
+	f, err := os.OpenFile(l.path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return LidStateUnknown, fmt.Errorf("opening lid device: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var bits [8]byte
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), eviocgsw, uintptr(unsafe.Pointer(&bits[0]))); errno != 0 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.last == LidStateUnknown {
+			return LidStateUnknown, fmt.Errorf("querying lid switch: %w", errno)
+		}
+		return l.last, nil
+	}
+
+	if bits[0]&(1<<swLid) != 0 {
+		return LidStateClosed, nil
+	}
+	return LidStateOpened, nil
+}
+
+func (l *EvdevLid) ListenForChanges(ctx context.Context) error {
+	defer close(l.Events)
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("opening lid device: %w", err)
+	}
+
+	// Closing the file is the only way to interrupt a blocked read.
+	stop := context.AfterFunc(ctx, func() {
+		_ = f.Close()
+	})
+	defer func() {
+		if stop() {
+			_ = f.Close()
+		}
+	}()
+
+	err = readLidEvents(f, func(s LidState) {
+		l.mu.Lock()
+		l.last = s
+		l.mu.Unlock()
+
+		select {
+		case l.Events <- struct{}{}:
+		case <-ctx.Done():
+		}
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readLidEvents reads input_event records from r until it ends, calling onChange for every
+// SW_LID event.
+func readLidEvents(r io.Reader, onChange func(LidState)) error {
+	buf := make([]byte, inputEventSize)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading lid device: %w", err)
+		}
+
+		// type, code and value follow the timeval
+		off := inputEventSize - 8
+		typ := binary.NativeEndian.Uint16(buf[off:])
+		code := binary.NativeEndian.Uint16(buf[off+2:])
+		value := int32(binary.NativeEndian.Uint32(buf[off+4:]))
+		if typ != evSW || code != swLid {
+			continue
+		}
+
+		if value != 0 {
+			onChange(LidStateClosed)
+		} else {
+			onChange(LidStateOpened)
+		}
+	}
+}
+
+// FindLidDevice returns the event device under devDir whose sysfs switch capabilities (under
+// sysDir, normally /sys/class/input) include SW_LID.
+func FindLidDevice(sysDir, devDir string) (string, error) {
+	caps, err := filepath.Glob(filepath.Join(sysDir, "event*", "device", "capabilities", "sw"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range caps {
+		b, err := os.ReadFile(c)
+		if err != nil {
+			continue
+		}
+
+		if hasLidSwitch(string(b)) {
+			// <sysDir>/eventN/device/capabilities/sw
+			event := filepath.Base(filepath.Dir(filepath.Dir(filepath.Dir(c))))
+			return filepath.Join(devDir, event), nil
+		}
+	}
+
+	return "", errors.New("no input device with a lid switch found")
+}
+
+// hasLidSwitch checks a sysfs capability bitmask, written as space-separated hex words with the
+// lowest bits last, for the SW_LID bit.
+func hasLidSwitch(bitmask string) bool {
+	words := strings.Fields(bitmask)
+	if len(words) == 0 {
+		return false
+	}
+
+	low, err := strconv.ParseUint(words[len(words)-1], 16, 64)
+	if err != nil {
+		return false
+	}
+
+	return low&(1<<swLid) != 0
+}
@@ -0,0 +1,50 @@
+package power
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const logindLidProperty = "LidClosed"
+
+// LogindLid reads the lid state from logind's LidClosed property. logind doesn't emit change
+// signals for it, so changes are polled.
+type LogindLid struct {
+	conn   *dbus.Conn
+	Events chan struct{}
+}
+
+func NewLogindLid(conn *dbus.Conn) *LogindLid {
+	return &LogindLid{
+		conn:   conn,
+		Events: make(chan struct{}, 10),
+	}
+}
+
+func (l *LogindLid) Changes() <-chan struct{} {
+	return l.Events
+}
+
+func (l *LogindLid) ListenForChanges(ctx context.Context) error {
+	defer close(l.Events)
+	return pollLid(ctx, l.GetCurrentState, l.Events)
+}
+
+func (l *LogindLid) GetCurrentState(ctx context.Context) (LidState, error) {
+	obj := l.conn.Object(logindDest, logindPath)
+	var result dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, logindManagerIfc, logindLidProperty).Store(&result); err != nil {
+		return LidStateUnknown, err
+	}
+
+	if closed, ok := result.Value().(bool); ok {
+		if closed {
+			return LidStateClosed, nil
+		}
+		return LidStateOpened, nil
+	}
+
+	return LidStateUnknown, fmt.Errorf("unexpected type for LidClosed")
+}
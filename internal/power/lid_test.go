@@ -0,0 +1,217 @@
+package power
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseACPILidState(t *testing.T) {
+	tests := []struct {
+		content string
+		want    LidState
+		wantErr bool
+	}{
+		{"state:      open\n", LidStateOpened, false},
+		{"state:      closed\n", LidStateClosed, false},
+		{"state: open", LidStateOpened, false},
+		{"state:      ajar\n", LidStateUnknown, true},
+		{"garbage", LidStateUnknown, true},
+		{"", LidStateUnknown, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseACPILidState(tt.content)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseACPILidState(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseACPILidState(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestACPILidGetCurrentState(t *testing.T) {
+	dir := t.TempDir()
+	l := NewACPILid(dir)
+	if _, err := l.GetCurrentState(context.Background()); err == nil {
+		t.Fatal("expected an error with no lid state file")
+	}
+
+	writeFile(t, filepath.Join(dir, "LID0", "state"), "state:      closed\n")
+	got, err := l.GetCurrentState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != LidStateClosed {
+		t.Errorf("state = %q, want %q", got, LidStateClosed)
+	}
+}
+
+// inputEvent encodes a struct input_event with a zero timestamp.
+func inputEvent(typ, code uint16, value int32) []byte {
+	b := make([]byte, inputEventSize)
+	off := inputEventSize - 8
+	binary.NativeEndian.PutUint16(b[off:], typ)
+	binary.NativeEndian.PutUint16(b[off+2:], code)
+	binary.NativeEndian.PutUint32(b[off+4:], uint32(value))
+	return b
+}
+
+func TestReadLidEvents(t *testing.T) {
+	const (
+		evSyn        = 0x00
+		evKey        = 0x01
+		swTabletMode = 0x01
+	)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	go func() {
+		for _, ev := range [][]byte{
+			inputEvent(evSW, swLid, 1),
+			inputEvent(evSyn, 0, 0),
+			inputEvent(evKey, swLid, 1), // same code, wrong type
+			inputEvent(evSW, swTabletMode, 1),
+			inputEvent(evSW, swLid, 0),
+		} {
+			_, _ = w.Write(ev)
+		}
+		_ = w.Close()
+	}()
+
+	var got []LidState
+	if err := readLidEvents(r, func(s LidState) { got = append(got, s) }); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LidState{LidStateClosed, LidStateOpened}
+	if !slices.Equal(got, want) {
+		t.Errorf("states = %v, want %v", got, want)
+	}
+}
+
+func TestReadLidEventsTruncated(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	go func() {
+		_, _ = w.Write(inputEvent(evSW, swLid, 1)[:inputEventSize/2])
+		_ = w.Close()
+	}()
+
+	if err := readLidEvents(r, func(LidState) {}); err == nil {
+		t.Error("expected an error for a partial event")
+	}
+}
+
+func TestHasLidSwitch(t *testing.T) {
+	tests := map[string]bool{
+		"1\n":     true,
+		"3":       true,
+		"0":       false,
+		"2":       false,
+		"1 0":     false, // SW_LID is in the last (lowest) word
+		"0 1":     true,
+		"":        false,
+		"not-hex": false,
+	}
+
+	for in, want := range tests {
+		if got := hasLidSwitch(in); got != want {
+			t.Errorf("hasLidSwitch(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFindLidDevice(t *testing.T) {
+	sys := t.TempDir()
+	writeFile(t, filepath.Join(sys, "event0", "device", "capabilities", "sw"), "0\n")
+	writeFile(t, filepath.Join(sys, "event3", "device", "capabilities", "sw"), "1\n")
+	writeFile(t, filepath.Join(sys, "event5", "device", "capabilities", "key"), "1\n")
+
+	got, err := FindLidDevice(sys, "/dev/input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/dev/input/event3"; got != want {
+		t.Errorf("FindLidDevice = %q, want %q", got, want)
+	}
+
+	if _, err := FindLidDevice(t.TempDir(), "/dev/input"); err == nil {
+		t.Error("expected an error with no lid switch device")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvdevLidFromPipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event0")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+
+	l := NewEvdevLid(path)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// with no event seen yet, there is no state to fall back on
+	if got, err := l.GetCurrentState(ctx); err == nil {
+		t.Errorf("state before any event = %q, want error", got)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- l.ListenForChanges(ctx)
+	}()
+
+	w, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write(inputEvent(evSW, swLid, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-l.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("no lid event")
+	}
+
+	// a pipe can't be queried, so the last event's state is used
+	got, err := l.GetCurrentState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != LidStateClosed {
+		t.Errorf("state = %q, want %q", got, LidStateClosed)
+	}
+
+	_ = w.Close()
+	if err := <-errc; err != nil {
+		t.Errorf("ListenForChanges: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const lidPollInterval = time.Second
+
+// LidBackend selects where the lid state is read from.
+type LidBackend string
+
+const (
+	LidBackendAuto   LidBackend = "auto"
+	LidBackendUPower LidBackend = "upower"
+	LidBackendLogind LidBackend = "logind"
+	LidBackendACPI   LidBackend = "acpi"
+	LidBackendEvdev  LidBackend = "evdev"
+)
+
+var (
+	_ LidSource = (*LidHandler)(nil)
+	_ LidSource = (*LogindLid)(nil)
+	_ LidSource = (*ACPILid)(nil)
+	_ LidSource = (*EvdevLid)(nil)
+
+	// autoLidBackends is the order backends are tried in when auto-detecting.
+	autoLidBackends = []LidBackend{LidBackendUPower, LidBackendLogind, LidBackendACPI, LidBackendEvdev}
+)
+
+// NewLidSource returns the lid source for backend. With LidBackendAuto (or an empty backend),
+// each backend is tried in turn and the first one that can read the lid state is used. device is
+// the evdev device path; if empty, one is detected from sysfs.
+func NewLidSource(ctx context.Context, conn *dbus.Conn, backend LidBackend, device string) (LidSource, LidBackend, error) {
+	if backend != "" && backend != LidBackendAuto {
+		src, err := newLidSource(conn, backend, device)
+		if err != nil {
+			return nil, backend, err
+		}
+		return src, backend, nil
+	}
+
+	var errs []error
+	for _, b := range autoLidBackends {
+		src, err := newLidSource(conn, b, device)
+		if err == nil {
+			_, err = src.GetCurrentState(ctx)
+		}
+
+		if err != nil {
+			slog.Debug("lid backend unavailable", "backend", b, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", b, err))
+			continue
+		}
+
+		return src, b, nil
+	}
+
+	return nil, LidBackendAuto, fmt.Errorf("no usable lid backend: %w", errors.Join(errs...))
+}
+
+func newLidSource(conn *dbus.Conn, backend LidBackend, device string) (LidSource, error) {
+	switch backend {
+	case LidBackendUPower:
+		return NewLidHandler(conn), nil
+	case LidBackendLogind:
+		return NewLogindLid(conn), nil
+	case LidBackendACPI:
+		return NewACPILid(defaultACPILidDir), nil
+	case LidBackendEvdev:
+		if device == "" {
+			d, err := FindLidDevice(defaultSysInputDir, defaultDevInputDir)
+			if err != nil {
+				return nil, err
+			}
+			device = d
+		}
+		return NewEvdevLid(device), nil
+	default:
+		return nil, fmt.Errorf("unknown lid backend %q", backend)
+	}
+}
+
+// pollLid reads the lid state every interval and signals events when it changes. It is used by
+// backends that have no change notifications.
+func pollLid(ctx context.Context, get func(context.Context) (LidState, error), events chan<- struct{}) error {
+	last, err := get(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := time.NewTicker(lidPollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			cur, err := get(ctx)
+			if err != nil {
+				slog.Debug("polling lid state", "error", err)
+				continue
+			}
+
+			if cur == last {
+				continue
+			}
+			last = cur
+
+			select {
+			case events <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -13,11 +13,25 @@ const (
 	upowerPath     = "/org/freedesktop/UPower"
 	upowerMatchIfc = "org.freedesktop.DBus.Properties"
 	upowerMatchMbr = "PropertiesChanged"
-	upowerMethod   = "org.freedesktop.DBus.Properties.Get"
 	onBatProperty  = "OnBattery"
 )
 
+// Methods of the standard D-Bus properties interface, which every service here implements.
+const (
+	propertiesGet = "org.freedesktop.DBus.Properties.Get"
+	propertiesSet = "org.freedesktop.DBus.Properties.Set"
+)
+
 type (
+	// Source reports whether the system runs on AC or battery. Changes receives a value whenever
+	// that may have changed, while ListenForChanges is running.
+	Source interface {
+		GetCurrentState(ctx context.Context) (State, error)
+		ListenForChanges(ctx context.Context) error
+		Changes() <-chan struct{}
+	}
+
+	// Handler is the UPower power source, using its OnBattery property.
 	Handler struct {
 		conn    *dbus.Conn
 		Events  chan struct{}
@@ -27,6 +41,15 @@ type (
 	State string
 )
 
+var (
+	_ Source            = (*Handler)(nil)
+	_ BatterySource     = (*BatteryHandler)(nil)
+	_ SleepSource       = (*SleepHandler)(nil)
+	_ Inhibitor         = (*SleepInhibitor)(nil)
+	_ SessionController = (*Sleeper)(nil)
+	_ ProfileController = (*ProfileManager)(nil)
+)
+
 const (
 	StateUnknown   State = "unknown"
 	StateOnBattery State = "battery"
@@ -41,6 +64,10 @@ func NewHandler(conn *dbus.Conn) *Handler {
 	}
 }
 
+func (p *Handler) Changes() <-chan struct{} {
+	return p.Events
+}
+
 func (p *Handler) ListenForChanges(ctx context.Context) error {
 	defer close(p.Events)
 	defer p.conn.RemoveSignal(p.signals)
@@ -85,7 +112,7 @@ func (p *Handler) startDbus(ctx context.Context) error {
 func (p *Handler) GetCurrentState(ctx context.Context) (State, error) {
 	obj := p.conn.Object(upowerDest, upowerPath)
 	var result dbus.Variant
-	if err := obj.CallWithContext(ctx, upowerMethod, 0, upowerDest, onBatProperty).Store(&result); err != nil {
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, upowerDest, onBatProperty).Store(&result); err != nil {
 		return StateUnknown, err
 	}
 
@@ -0,0 +1,125 @@
+package power
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	profilesDest      = "net.hadess.PowerProfiles"
+	profilesPath      = "/net/hadess/PowerProfiles"
+	profilesIfc       = "net.hadess.PowerProfiles"
+	activeProfileProp = "ActiveProfile"
+	profilesProperty  = "Profiles"
+	holdsProperty     = "ActiveProfileHolds"
+	profileNameKey    = "Profile"
+	holdAppKey        = "ApplicationId"
+)
+
+// ProfileHold is a request by another application (e.g. a desktop's low battery handling) to
+// keep a profile active. The daemon switches back when the last hold is released.
+type ProfileHold struct {
+	Profile       string
+	ApplicationID string
+}
+
+// ProfileController reads and switches the active power profile.
+type ProfileController interface {
+	ActiveProfile(ctx context.Context) (string, error)
+	Holds(ctx context.Context) ([]ProfileHold, error)
+	SetActiveProfile(ctx context.Context, profile string) error
+}
+
+// ProfileManager reads and switches the active power-profiles-daemon profile.
+type ProfileManager struct {
+	conn *dbus.Conn
+}
+
+func NewProfileManager(conn *dbus.Conn) *ProfileManager {
+	return &ProfileManager{conn: conn}
+}
+
+// ActiveProfile returns the currently active profile, e.g. "balanced".
+func (p *ProfileManager) ActiveProfile(ctx context.Context) (string, error) {
+	obj := p.conn.Object(profilesDest, profilesPath)
+	var v dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, profilesIfc, activeProfileProp).Store(&v); err != nil {
+		return "", fmt.Errorf("getting active power profile: %w", err)
+	}
+
+	s, ok := v.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected %s type %T", activeProfileProp, v.Value())
+	}
+
+	return s, nil
+}
+
+// Profiles returns the names of the profiles available on this system.
+func (p *ProfileManager) Profiles(ctx context.Context) ([]string, error) {
+	obj := p.conn.Object(profilesDest, profilesPath)
+	var v dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, profilesIfc, profilesProperty).Store(&v); err != nil {
+		return nil, fmt.Errorf("getting power profiles: %w", err)
+	}
+
+	list, ok := v.Value().([]map[string]dbus.Variant)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s type %T", profilesProperty, v.Value())
+	}
+
+	var names []string
+	for _, prof := range list {
+		if name, ok := prof[profileNameKey].Value().(string); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// Holds returns the profile holds currently in place.
+func (p *ProfileManager) Holds(ctx context.Context) ([]ProfileHold, error) {
+	obj := p.conn.Object(profilesDest, profilesPath)
+	var v dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, profilesIfc, holdsProperty).Store(&v); err != nil {
+		return nil, fmt.Errorf("getting power profile holds: %w", err)
+	}
+
+	list, ok := v.Value().([]map[string]dbus.Variant)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s type %T", holdsProperty, v.Value())
+	}
+
+	var holds []ProfileHold
+	for _, h := range list {
+		var hold ProfileHold
+		hold.Profile, _ = h[profileNameKey].Value().(string)
+		hold.ApplicationID, _ = h[holdAppKey].Value().(string)
+		holds = append(holds, hold)
+	}
+
+	return holds, nil
+}
+
+// SetActiveProfile switches to profile after checking that it is available.
+func (p *ProfileManager) SetActiveProfile(ctx context.Context, profile string) error {
+	available, err := p.Profiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(available, profile) {
+		return fmt.Errorf("power profile %q not available (have %v)", profile, available)
+	}
+
+	obj := p.conn.Object(profilesDest, profilesPath)
+	if err := obj.CallWithContext(ctx, propertiesSet, 0, profilesIfc, activeProfileProp, dbus.MakeVariant(profile)).Err; err != nil {
+		return fmt.Errorf("setting power profile: %w", err)
+	}
+
+	return nil
+}
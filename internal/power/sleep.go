@@ -0,0 +1,209 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	logindDest       = "org.freedesktop.login1"
+	logindPath       = "/org/freedesktop/login1"
+	logindManagerIfc = "org.freedesktop.login1.Manager"
+	logindUserIfc    = "org.freedesktop.login1.User"
+	logindSessionIfc = "org.freedesktop.login1.Session"
+	prepareForSleep  = "PrepareForSleep"
+)
+
+// SleepSource reports when the system is about to sleep. Changes receives true right before the
+// system sleeps and false once it has woken up, while ListenForChanges is running.
+type SleepSource interface {
+	ListenForChanges(ctx context.Context) error
+	Changes() <-chan bool
+}
+
+// SleepHandler listens for logind's PrepareForSleep signal. Events receives true right before
+// the system sleeps, and false once it has woken up.
+type SleepHandler struct {
+	conn    *dbus.Conn
+	Events  chan bool
+	signals chan *dbus.Signal
+}
+
+func NewSleepHandler(conn *dbus.Conn) *SleepHandler {
+	return &SleepHandler{
+		conn:    conn,
+		Events:  make(chan bool, 10),
+		signals: make(chan *dbus.Signal, 10),
+	}
+}
+
+func (s *SleepHandler) Changes() <-chan bool {
+	return s.Events
+}
+
+func (s *SleepHandler) ListenForChanges(ctx context.Context) error {
+	defer close(s.Events)
+	defer s.conn.RemoveSignal(s.signals)
+	if err := s.startDbus(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case sig, ok := <-s.signals:
+			if !ok {
+				return fmt.Errorf("signals channel closed")
+			}
+
+			sleeping, ok := parseSleepSignal(sig)
+			if !ok {
+				continue
+			}
+
+			select {
+			case s.Events <- sleeping:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *SleepHandler) startDbus(ctx context.Context) error {
+	if err := s.conn.AddMatchSignalContext(
+		ctx, dbus.WithMatchInterface(logindManagerIfc), dbus.WithMatchMember(prepareForSleep),
+		dbus.WithMatchObjectPath(dbus.ObjectPath(logindPath)),
+	); err != nil {
+		return fmt.Errorf("failed to add dbus match rule: %w", err)
+	}
+
+	s.conn.Signal(s.signals)
+	return nil
+}
+
+func parseSleepSignal(sig *dbus.Signal) (sleeping bool, ok bool) {
+	if sig.Name != logindManagerIfc+"."+prepareForSleep || len(sig.Body) < 1 {
+		return false, false
+	}
+
+	sleeping, ok = sig.Body[0].(bool)
+	return sleeping, ok
+}
+
+// SleepAction is a logind sleep operation.
+type SleepAction string
+
+const (
+	SleepSuspend              SleepAction = "suspend"
+	SleepHibernate            SleepAction = "hibernate"
+	SleepHybridSleep          SleepAction = "hybrid-sleep"
+	SleepSuspendThenHibernate SleepAction = "suspend-then-hibernate"
+)
+
+// sleepMethods maps each action to its logind Manager method. Each method has a matching
+// Can<Method> check.
+var sleepMethods = map[SleepAction]string{
+	SleepSuspend:              "Suspend",
+	SleepHibernate:            "Hibernate",
+	SleepHybridSleep:          "HybridSleep",
+	SleepSuspendThenHibernate: "SuspendThenHibernate",
+}
+
+// Valid reports whether a is a sleep action logind knows.
+func (a SleepAction) Valid() bool {
+	_, ok := sleepMethods[a]
+	return ok
+}
+
+// SessionController puts the system to sleep and locks the user's session.
+type SessionController interface {
+	Sleep(ctx context.Context, action SleepAction) error
+	Lock(ctx context.Context) error
+}
+
+// Sleeper puts the system to sleep through logind.
+type Sleeper struct {
+	conn *dbus.Conn
+}
+
+func NewSleeper(conn *dbus.Conn) *Sleeper {
+	return &Sleeper{conn: conn}
+}
+
+// Sleep checks that action is supported and permitted, then asks logind to perform it.
+func (s *Sleeper) Sleep(ctx context.Context, action SleepAction) error {
+	method, ok := sleepMethods[action]
+	if !ok {
+		return fmt.Errorf("unknown sleep action %q", action)
+	}
+
+	obj := s.conn.Object(logindDest, logindPath)
+	var can string
+	if err := obj.CallWithContext(ctx, logindManagerIfc+".Can"+method, 0).Store(&can); err != nil {
+		return fmt.Errorf("checking if %s is allowed: %w", action, err)
+	}
+
+	switch can {
+	case "yes":
+	case "na":
+		return fmt.Errorf("%s is not supported on this system (Can%s: na)", action, method)
+	case "no":
+		return fmt.Errorf("%s is not permitted for this user (Can%s: no)", action, method)
+	case "challenge":
+		return fmt.Errorf("%s requires authorization (Can%s: challenge)", action, method)
+	default:
+		return fmt.Errorf("unexpected Can%s result %q", method, can)
+	}
+
+	// interactive=false: never prompt for authorization from a background service
+	if err := obj.CallWithContext(ctx, logindManagerIfc+"."+method, 0, false).Err; err != nil {
+		return fmt.Errorf("requesting %s: %w", action, err)
+	}
+
+	return nil
+}
+
+// Lock asks logind to lock the user's graphical session, which signals the session's screen
+// locker. The session is $XDG_SESSION_ID if set, otherwise the user's display session, since a
+// user service doesn't run inside a session itself.
+func (s *Sleeper) Lock(ctx context.Context) error {
+	mgr := s.conn.Object(logindDest, logindPath)
+	if id := os.Getenv("XDG_SESSION_ID"); id != "" {
+		if err := mgr.CallWithContext(ctx, logindManagerIfc+".LockSession", 0, id).Err; err != nil {
+			return fmt.Errorf("locking session %s: %w", id, err)
+		}
+		return nil
+	}
+
+	var userPath dbus.ObjectPath
+	if err := mgr.CallWithContext(ctx, logindManagerIfc+".GetUser", 0, uint32(os.Getuid())).Store(&userPath); err != nil {
+		return fmt.Errorf("getting logind user: %w", err)
+	}
+
+	var display dbus.Variant
+	if err := s.conn.Object(logindDest, userPath).CallWithContext(ctx, propertiesGet, 0, logindUserIfc, "Display").Store(&display); err != nil {
+		return fmt.Errorf("getting display session: %w", err)
+	}
+
+	// Display is a (so) struct of session ID and object path
+	fields, ok := display.Value().([]any)
+	if !ok || len(fields) != 2 {
+		return fmt.Errorf("unexpected Display value %v", display.Value())
+	}
+	sessionPath, ok := fields[1].(dbus.ObjectPath)
+	if !ok || sessionPath == "/" {
+		return errors.New("no graphical session to lock")
+	}
+
+	if err := s.conn.Object(logindDest, sessionPath).CallWithContext(ctx, logindSessionIfc+".Lock", 0).Err; err != nil {
+		return fmt.Errorf("locking session: %w", err)
+	}
+
+	return nil
+}
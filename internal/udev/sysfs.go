@@ -0,0 +1,106 @@
+package udev
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSysRoot is where sysfs is mounted.
+const DefaultSysRoot = "/sys"
+
+// Device is a device directory in sysfs and its attribute files.
+type Device struct {
+	Subsystem string
+	DevPath   string // relative to the sysfs root, like Event.DevPath
+	Attrs     map[string]string
+	dir       string
+}
+
+// Attr returns the named attribute, reading it from sysfs if it wasn't read up front. Missing
+// attributes are returned as "".
+func (d Device) Attr(name string) string {
+	if v, ok := d.Attrs[name]; ok {
+		return v
+	}
+	if d.dir == "" {
+		return ""
+	}
+
+	v, ok := ReadAttrs(d.dir, name)[name]
+	if ok && d.Attrs != nil {
+		d.Attrs[name] = v
+	}
+
+	return v
+}
+
+// ScanSubsystem reads every device listed under <sysRoot>/bus/<subsystem>/devices. Only the
+// named attributes are read up front; missing ones are left out of Attrs.
+func ScanSubsystem(sysRoot, subsystem string, attrs ...string) ([]Device, error) {
+	dir := filepath.Join(sysRoot, "bus", subsystem, "devices")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var devices []Device
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			path = real
+		}
+
+		devices = append(devices, Device{
+			Subsystem: subsystem,
+			DevPath:   devPath(sysRoot, path),
+			Attrs:     ReadAttrs(path, attrs...),
+			dir:       path,
+		})
+	}
+
+	return devices, nil
+}
+
+// ReadDevice reads the named attributes of the device at devPath (relative to sysRoot). Others
+// can be read later with Attr.
+func ReadDevice(sysRoot, subsystem, devPath string, attrs ...string) Device {
+	dir := filepath.Join(sysRoot, devPath)
+	return Device{
+		Subsystem: subsystem,
+		DevPath:   devPath,
+		Attrs:     ReadAttrs(dir, attrs...),
+		dir:       dir,
+	}
+}
+
+// ReadAttrs reads the named attribute files in dir, trimming whitespace.
+func ReadAttrs(dir string, attrs ...string) map[string]string {
+	vals := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		b, err := os.ReadFile(filepath.Join(dir, a))
+		if err != nil {
+			continue
+		}
+		vals[a] = strings.TrimSpace(string(b))
+	}
+
+	return vals
+}
+
+func devPath(sysRoot, path string) string {
+	root, err := filepath.EvalSymlinks(sysRoot)
+	if err != nil {
+		root = sysRoot
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+
+	return "/" + rel
+}
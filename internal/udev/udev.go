@@ -0,0 +1,130 @@
+// Package udev reads kernel device events from the uevent netlink socket, and device attributes
+// from sysfs.
+package udev
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+const (
+	// kernelGroup is the netlink multicast group the kernel sends uevents to. udevd rebroadcasts
+	// them on group 2 in its own binary format, which isn't needed here.
+	kernelGroup   = 1
+	ueventBufSize = 64 * 1024
+)
+
+type (
+	// Monitor receives kernel uevents over netlink.
+	Monitor struct {
+		f *os.File
+	}
+
+	// Event is a single kernel uevent.
+	Event struct {
+		Action    string // add, remove, change, bind, unbind...
+		DevPath   string // path under /sys, e.g. /devices/pci0000:00/.../3-1
+		Subsystem string
+		Env       map[string]string
+	}
+)
+
+func NewMonitor() (*Monitor, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("creating netlink socket: %w", err)
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: kernelGroup}); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("binding netlink socket: %w", err)
+	}
+
+	// Non-blocking so the runtime poller manages it and Close interrupts a pending Read.
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("setting netlink socket non-blocking: %w", err)
+	}
+
+	return &Monitor{f: os.NewFile(uintptr(fd), "uevent")}, nil
+}
+
+// Listen sends every uevent in one of subsystems (or every uevent, if none are given) to events
+// until ctx is done or the socket fails.
+func (m *Monitor) Listen(ctx context.Context, events chan<- Event, subsystems ...string) error {
+	stop := context.AfterFunc(ctx, func() {
+		_ = m.f.Close()
+	})
+	defer stop()
+
+	buf := make([]byte, ueventBufSize)
+	for {
+		n, err := m.f.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("reading uevent: %w", err)
+		}
+
+		ev, ok := ParseEvent(buf[:n])
+		if !ok {
+			continue
+		}
+
+		if len(subsystems) > 0 && !slices.Contains(subsystems, ev.Subsystem) {
+			continue
+		}
+
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (m *Monitor) Close() error {
+	return m.f.Close()
+}
+
+// ParseEvent parses a kernel uevent message: "ACTION@DEVPATH" followed by NUL-separated
+// KEY=VALUE pairs. Messages rebroadcast by udevd (starting with "libudev") are rejected.
+func ParseEvent(msg []byte) (Event, bool) {
+	parts := bytes.Split(msg, []byte{0})
+	if len(parts) == 0 || bytes.HasPrefix(parts[0], []byte("libudev")) {
+		return Event{}, false
+	}
+
+	if !bytes.Contains(parts[0], []byte("@")) {
+		return Event{}, false
+	}
+
+	ev := Event{Env: make(map[string]string)}
+	for _, p := range parts[1:] {
+		k, v, ok := strings.Cut(string(p), "=")
+		if !ok {
+			continue
+		}
+		ev.Env[k] = v
+	}
+
+	ev.Action = ev.Env["ACTION"]
+	ev.DevPath = ev.Env["DEVPATH"]
+	ev.Subsystem = ev.Env["SUBSYSTEM"]
+	if ev.Action == "" || ev.DevPath == "" {
+		action, devpath, _ := strings.Cut(string(parts[0]), "@")
+		ev.Action, ev.DevPath = action, devpath
+	}
+
+	return ev, true
+}